@@ -0,0 +1,172 @@
+// Package auth authenticates API callers with HS256 JWT bearer tokens or
+// static API keys and authorizes them by role.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/model"
+)
+
+// Role is what a caller may do. Each role includes the ones below it.
+type Role string
+
+const (
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+var roleRank = map[Role]int{RoleViewer: 1, RoleEditor: 2, RoleAdmin: 3}
+
+// Valid reports whether r is a known role.
+func (r Role) Valid() bool {
+	return roleRank[r] > 0
+}
+
+// Allows reports whether r grants at least the permissions of required. An
+// unknown required role is granted to nobody.
+func (r Role) Allows(required Role) bool {
+	return r.Valid() && required.Valid() && roleRank[r] >= roleRank[required]
+}
+
+// Identity is the authenticated caller.
+type Identity struct {
+	Subject string `json:"sub"`
+	Role    Role   `json:"role"`
+}
+
+// APIKey maps a static key to the identity it authenticates as.
+type APIKey struct {
+	Key     string
+	Subject string
+	Role    Role
+}
+
+var (
+	ErrNoCredentials      = errors.New("auth: no credentials")
+	ErrInvalidCredentials = errors.New("auth: invalid credentials")
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying id.
+func NewContext(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the caller identity stored by the middleware, if any.
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(contextKey{}).(Identity)
+	return id, ok
+}
+
+// Authenticator checks bearer tokens against the signing key and API keys
+// against the configured list, and signs the confirm tokens destructive
+// requests ask for.
+type Authenticator struct {
+	secret     []byte
+	confirmKey []byte
+	keys       map[[sha256.Size]byte]Identity
+}
+
+// NewAuthenticator returns an Authenticator. An empty secret disables JWTs
+// and limits confirm tokens to this process.
+func NewAuthenticator(secret string, keys []APIKey) *Authenticator {
+	a := &Authenticator{
+		secret:     []byte(secret),
+		confirmKey: confirmKey([]byte(secret)),
+		keys:       map[[sha256.Size]byte]Identity{},
+	}
+	for _, k := range keys {
+		a.keys[sha256.Sum256([]byte(k.Key))] = Identity{Subject: k.Subject, Role: k.Role}
+	}
+	return a
+}
+
+// Enabled reports whether any credential can be accepted at all.
+func (a *Authenticator) Enabled() bool {
+	return len(a.secret) > 0 || len(a.keys) > 0
+}
+
+// Authenticate resolves the caller of r from the Authorization: Bearer or
+// X-API-Key header.
+func (a *Authenticator) Authenticate(r *http.Request) (Identity, error) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return a.lookupKey(key)
+	}
+
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return Identity{}, ErrNoCredentials
+	}
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return Identity{}, ErrInvalidCredentials
+	}
+	if len(a.secret) == 0 {
+		return Identity{}, ErrInvalidCredentials
+	}
+	return Verify(a.secret, strings.TrimSpace(token), time.Now())
+}
+
+func (a *Authenticator) lookupKey(key string) (Identity, error) {
+	sum := sha256.Sum256([]byte(key))
+	for known, id := range a.keys {
+		if subtle.ConstantTimeCompare(known[:], sum[:]) == 1 {
+			return id, nil
+		}
+	}
+	return Identity{}, ErrInvalidCredentials
+}
+
+// Middleware stores the caller identity in the request context. Requests
+// without credentials continue anonymously; bad credentials are rejected.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := a.Authenticate(r)
+		switch err {
+		case nil:
+			r = r.WithContext(NewContext(r.Context(), id))
+		case ErrNoCredentials:
+		default:
+			deny(w, http.StatusUnauthorized, "Invalid credentials")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Require only lets callers holding at least role reach next.
+func Require(role Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := FromContext(r.Context())
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mongoapi"`)
+			deny(w, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+		if !id.Role.Allows(role) {
+			deny(w, http.StatusForbidden, "Requires role "+string(role))
+			return
+		}
+		next(w, r)
+	}
+}
+
+func deny(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(model.Response{
+		Message:    message,
+		StatusCode: statusCode,
+		Data:       "Error",
+	})
+}
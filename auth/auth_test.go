@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/model"
+)
+
+func TestRoleAllows(t *testing.T) {
+	roles := []Role{RoleViewer, RoleEditor, RoleAdmin, "root", ""}
+	// want[i][j] is whether roles[i] allows roles[j]
+	want := [][]bool{
+		{true, false, false, false, false},
+		{true, true, false, false, false},
+		{true, true, true, false, false},
+		{false, false, false, false, false},
+		{false, false, false, false, false},
+	}
+	for i, have := range roles {
+		for j, required := range roles {
+			if got := have.Allows(required); got != want[i][j] {
+				t.Errorf("Role(%q).Allows(%q) = %v, want %v", have, required, got, want[i][j])
+			}
+		}
+	}
+}
+
+const testKey = "viewer-key-0123456789"
+
+func newTestAuthenticator() *Authenticator {
+	return NewAuthenticator(string(testSecret), []APIKey{{Key: testKey, Subject: "dashboard", Role: RoleViewer}})
+}
+
+// identityHandler answers with the identity the middleware stored, if any.
+func identityHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := FromContext(r.Context())
+	if !ok {
+		id = Identity{Subject: "anonymous"}
+	}
+	json.NewEncoder(w).Encode(id)
+}
+
+func TestMiddleware(t *testing.T) {
+	token, err := Sign(testSecret, Identity{Subject: "asha", Role: RoleEditor}, time.Now(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := Sign(testSecret, Identity{Subject: "asha", Role: RoleEditor}, time.Now().Add(-2*time.Hour), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		status int
+		want   Identity
+	}{
+		{name: "anonymous", status: http.StatusOK, want: Identity{Subject: "anonymous"}},
+		{name: "bearer", header: map[string]string{"Authorization": "Bearer " + token}, status: http.StatusOK, want: Identity{Subject: "asha", Role: RoleEditor}},
+		{name: "bearer any case", header: map[string]string{"Authorization": "bearer " + token}, status: http.StatusOK, want: Identity{Subject: "asha", Role: RoleEditor}},
+		{name: "api key", header: map[string]string{"X-API-Key": testKey}, status: http.StatusOK, want: Identity{Subject: "dashboard", Role: RoleViewer}},
+		{name: "api key wins", header: map[string]string{"X-API-Key": testKey, "Authorization": "Bearer " + token}, status: http.StatusOK, want: Identity{Subject: "dashboard", Role: RoleViewer}},
+		{name: "unknown api key", header: map[string]string{"X-API-Key": testKey + "x"}, status: http.StatusUnauthorized},
+		{name: "bad api key beside good token", header: map[string]string{"X-API-Key": "nope", "Authorization": "Bearer " + token}, status: http.StatusUnauthorized},
+		{name: "expired bearer", header: map[string]string{"Authorization": "Bearer " + expired}, status: http.StatusUnauthorized},
+		{name: "garbage bearer", header: map[string]string{"Authorization": "Bearer not.a.token"}, status: http.StatusUnauthorized},
+		{name: "basic scheme", header: map[string]string{"Authorization": "Basic YWRtaW46YWRtaW4="}, status: http.StatusUnauthorized},
+		{name: "no scheme", header: map[string]string{"Authorization": token}, status: http.StatusUnauthorized},
+	}
+	h := newTestAuthenticator().Middleware(http.HandlerFunc(identityHandler))
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tc.status, rec.Body)
+			}
+			if tc.status != http.StatusOK {
+				wantResponse(t, rec, "Invalid credentials")
+				return
+			}
+			var got Identity
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("identity = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBearerWithoutSecret(t *testing.T) {
+	token, err := Sign(testSecret, Identity{Subject: "asha", Role: RoleAdmin}, time.Now(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if _, err := NewAuthenticator("", nil).Authenticate(req); err != ErrInvalidCredentials {
+		t.Errorf("Authenticate = %v, want ErrInvalidCredentials when JWTs are disabled", err)
+	}
+}
+
+func TestRequire(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *Identity
+		required Role
+		status   int
+		message  string
+	}{
+		{name: "no identity", required: RoleViewer, status: http.StatusUnauthorized, message: "Authentication required"},
+		{name: "viewer for editor", identity: &Identity{Subject: "a", Role: RoleViewer}, required: RoleEditor, status: http.StatusForbidden, message: "Requires role editor"},
+		{name: "editor for admin", identity: &Identity{Subject: "a", Role: RoleEditor}, required: RoleAdmin, status: http.StatusForbidden, message: "Requires role admin"},
+		{name: "unknown role", identity: &Identity{Subject: "a", Role: "root"}, required: RoleViewer, status: http.StatusForbidden, message: "Requires role viewer"},
+		{name: "exact role", identity: &Identity{Subject: "a", Role: RoleEditor}, required: RoleEditor, status: http.StatusOK},
+		{name: "higher role", identity: &Identity{Subject: "a", Role: RoleAdmin}, required: RoleViewer, status: http.StatusOK},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.identity != nil {
+				req = req.WithContext(NewContext(req.Context(), *tc.identity))
+			}
+			rec := httptest.NewRecorder()
+			Require(tc.required, identityHandler)(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tc.status, rec.Body)
+			}
+			if tc.status == http.StatusOK {
+				return
+			}
+			wantResponse(t, rec, tc.message)
+			challenge := rec.Header().Get("WWW-Authenticate")
+			if (tc.status == http.StatusUnauthorized) != (challenge != "") {
+				t.Errorf("WWW-Authenticate = %q on a %d", challenge, tc.status)
+			}
+		})
+	}
+}
+
+func wantResponse(t *testing.T, rec *httptest.ResponseRecorder, message string) {
+	t.Helper()
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var resp model.Response
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Message != message || resp.StatusCode != rec.Code || resp.Data != "Error" {
+		t.Errorf("response = %+v, want message %q", resp, message)
+	}
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// confirmKey derives the key confirm tokens are signed with, so a confirm
+// token can never pass for a JWT signature or the other way round. Without a
+// secret the key is drawn at random and only this process can check tokens.
+func confirmKey(secret []byte) []byte {
+	if len(secret) == 0 {
+		key := make([]byte, sha256.Size)
+		rand.Read(key)
+		return key
+	}
+	return mac(secret, "mongoapi confirm token")
+}
+
+// ConfirmToken returns a token allowing subject to confirm action until
+// expires. The token is an HMAC over action, subject and expiry, so any
+// replica sharing the JWT secret accepts it and nothing needs storing. It
+// is not single use: it can be replayed until it expires.
+func (a *Authenticator) ConfirmToken(action, subject string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return exp + "." + rawURL.EncodeToString(a.confirmMAC(action, subject, exp))
+}
+
+// CheckConfirmToken reports whether token was issued to subject for action
+// and has not expired at now.
+func (a *Authenticator) CheckConfirmToken(token, action, subject string, now time.Time) bool {
+	exp, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || !now.Before(time.Unix(expires, 0)) {
+		return false
+	}
+	got, err := rawURL.DecodeString(signature)
+	return err == nil && hmac.Equal(got, a.confirmMAC(action, subject, exp))
+}
+
+func (a *Authenticator) confirmMAC(action, subject, exp string) []byte {
+	// NUL cannot occur in the parts, so they cannot run into each other
+	return mac(a.confirmKey, action+"\x00"+subject+"\x00"+exp)
+}
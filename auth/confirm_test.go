@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfirmToken(t *testing.T) {
+	a := NewAuthenticator(string(testSecret), nil)
+	expires := testNow.Add(5 * time.Minute)
+	token := a.ConfirmToken("delete all contacts", "asha", expires)
+	exp, signature, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name          string
+		checker       *Authenticator
+		token         string
+		action, actor string
+		now           time.Time
+		ok            bool
+	}{
+		{name: "valid", checker: a, token: token, action: "delete all contacts", actor: "asha", now: testNow, ok: true},
+		{name: "other replica", checker: NewAuthenticator(string(testSecret), nil), token: token, action: "delete all contacts", actor: "asha", now: testNow, ok: true},
+		{name: "reused before expiry", checker: a, token: token, action: "delete all contacts", actor: "asha", now: expires.Add(-time.Second), ok: true},
+		{name: "expired", checker: a, token: token, action: "delete all contacts", actor: "asha", now: expires},
+		{name: "other secret", checker: NewAuthenticator("another secret", nil), token: token, action: "delete all contacts", actor: "asha", now: testNow},
+		{name: "other action", checker: a, token: token, action: "purge the whole trash", actor: "asha", now: testNow},
+		{name: "other caller", checker: a, token: token, action: "delete all contacts", actor: "ravi", now: testNow},
+		{name: "extended expiry", checker: a, token: "9999999999." + signature, action: "delete all contacts", actor: "asha", now: testNow},
+		{name: "bad signature", checker: a, token: exp + ".AAAA", action: "delete all contacts", actor: "asha", now: testNow},
+		{name: "no signature", checker: a, token: exp, action: "delete all contacts", actor: "asha", now: testNow},
+		{name: "jwt signature", checker: a, token: exp + "." + rawURL.EncodeToString(mac(testSecret, "delete all contacts\x00asha\x00"+exp)), action: "delete all contacts", actor: "asha", now: testNow},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.checker.CheckConfirmToken(tc.token, tc.action, tc.actor, tc.now); got != tc.ok {
+				t.Errorf("CheckConfirmToken = %v, want %v", got, tc.ok)
+			}
+		})
+	}
+}
+
+func TestConfirmTokenWithoutSecret(t *testing.T) {
+	a := NewAuthenticator("", nil)
+	token := a.ConfirmToken("delete all contacts", "asha", testNow.Add(time.Minute))
+	if !a.CheckConfirmToken(token, "delete all contacts", "asha", testNow) {
+		t.Error("token rejected by the instance that issued it")
+	}
+	if NewAuthenticator("", nil).CheckConfirmToken(token, "delete all contacts", "asha", testNow) {
+		t.Error("token accepted by another instance without a shared secret")
+	}
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// clockSkew is how far token timestamps may disagree with the local clock.
+const clockSkew = 30 * time.Second
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+type claims struct {
+	Subject   string `json:"sub"`
+	Role      Role   `json:"role"`
+	IssuedAt  int64  `json:"iat"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+var rawURL = base64.RawURLEncoding
+
+// Sign issues an HS256 JWT for id that expires after ttl.
+func Sign(secret []byte, id Identity, now time.Time, ttl time.Duration) (string, error) {
+	header, err := json.Marshal(jwtHeader{Alg: "HS256", Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims{
+		Subject:   id.Subject,
+		Role:      id.Role,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := rawURL.EncodeToString(header) + "." + rawURL.EncodeToString(payload)
+	return signingInput + "." + rawURL.EncodeToString(mac(secret, signingInput)), nil
+}
+
+// Verify checks the signature and lifetime of an HS256 token and returns the
+// identity it carries.
+func Verify(secret []byte, token string, now time.Time) (Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Identity{}, ErrInvalidCredentials
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return Identity{}, ErrInvalidCredentials
+	}
+
+	signature, err := rawURL.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, mac(secret, parts[0]+"."+parts[1])) {
+		return Identity{}, ErrInvalidCredentials
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return Identity{}, ErrInvalidCredentials
+	}
+	if c.ExpiresAt == 0 || now.After(time.Unix(c.ExpiresAt, 0).Add(clockSkew)) {
+		return Identity{}, ErrInvalidCredentials
+	}
+	if c.NotBefore != 0 && now.Add(clockSkew).Before(time.Unix(c.NotBefore, 0)) {
+		return Identity{}, ErrInvalidCredentials
+	}
+	if c.Subject == "" || !c.Role.Valid() {
+		return Identity{}, ErrInvalidCredentials
+	}
+
+	return Identity{Subject: c.Subject, Role: c.Role}, nil
+}
+
+func mac(secret []byte, signingInput string) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(signingInput))
+	return h.Sum(nil)
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	raw, err := rawURL.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
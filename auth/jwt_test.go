@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testSecret = []byte("0123456789abcdef0123456789abcdef")
+	testNow    = time.Unix(1_700_000_000, 0)
+)
+
+// forge signs arbitrary header and claims with secret, for building tokens
+// Sign never issues.
+func forge(t *testing.T, secret []byte, header, payload any) string {
+	t.Helper()
+	h, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingInput := rawURL.EncodeToString(h) + "." + rawURL.EncodeToString(p)
+	return signingInput + "." + rawURL.EncodeToString(mac(secret, signingInput))
+}
+
+func TestSignVerify(t *testing.T) {
+	id := Identity{Subject: "asha", Role: RoleEditor}
+	token, err := Sign(testSecret, id, testNow, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Verify(testSecret, token, testNow.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != id {
+		t.Errorf("Verify = %+v, want %+v", got, id)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	hs256 := jwtHeader{Alg: "HS256", Typ: "JWT"}
+	valid := claims{Subject: "asha", Role: RoleViewer, IssuedAt: testNow.Unix(), ExpiresAt: testNow.Add(time.Hour).Unix()}
+	with := func(edit func(c *claims)) claims {
+		c := valid
+		edit(&c)
+		return c
+	}
+	signed, err := Sign(testSecret, Identity{Subject: "asha", Role: RoleViewer}, testNow, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(signed, ".")
+
+	tests := []struct {
+		name  string
+		token string
+		now   time.Time
+		ok    bool
+	}{
+		{name: "valid", token: forge(t, testSecret, hs256, valid), now: testNow, ok: true},
+		{name: "expired", token: forge(t, testSecret, hs256, valid), now: testNow.Add(time.Hour + clockSkew + time.Second)},
+		{name: "expired within skew", token: forge(t, testSecret, hs256, valid), now: testNow.Add(time.Hour + clockSkew), ok: true},
+		{name: "no expiry", token: forge(t, testSecret, hs256, with(func(c *claims) { c.ExpiresAt = 0 })), now: testNow},
+		{
+			name:  "not yet valid",
+			token: forge(t, testSecret, hs256, with(func(c *claims) { c.NotBefore = testNow.Add(time.Minute).Unix() })),
+			now:   testNow,
+		},
+		{
+			name:  "not before within skew",
+			token: forge(t, testSecret, hs256, with(func(c *claims) { c.NotBefore = testNow.Add(clockSkew).Unix() })),
+			now:   testNow,
+			ok:    true,
+		},
+		{name: "tampered payload", token: parts[0] + "." + rawURL.EncodeToString([]byte(`{"sub":"asha","role":"admin","exp":9999999999}`)) + "." + parts[2], now: testNow},
+		{name: "tampered signature", token: parts[0] + "." + parts[1] + "." + rawURL.EncodeToString([]byte("forged")), now: testNow},
+		{name: "other secret", token: forge(t, []byte("another secret"), hs256, valid), now: testNow},
+		{name: "alg none", token: forge(t, testSecret, jwtHeader{Alg: "none", Typ: "JWT"}, valid), now: testNow},
+		{name: "alg none unsigned", token: rawURL.EncodeToString([]byte(`{"alg":"none"}`)) + "." + parts[1] + ".", now: testNow},
+		{name: "alg HS512", token: forge(t, testSecret, jwtHeader{Alg: "HS512", Typ: "JWT"}, valid), now: testNow},
+		{name: "alg lower case", token: forge(t, testSecret, jwtHeader{Alg: "hs256", Typ: "JWT"}, valid), now: testNow},
+		{name: "unknown role", token: forge(t, testSecret, hs256, with(func(c *claims) { c.Role = "root" })), now: testNow},
+		{name: "no role", token: forge(t, testSecret, hs256, with(func(c *claims) { c.Role = "" })), now: testNow},
+		{name: "no subject", token: forge(t, testSecret, hs256, with(func(c *claims) { c.Subject = "" })), now: testNow},
+		{name: "two segments", token: parts[0] + "." + parts[1], now: testNow},
+		{name: "bad base64", token: "!!." + parts[1] + "." + parts[2], now: testNow},
+		{name: "empty", token: "", now: testNow},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			id, err := Verify(testSecret, tc.token, tc.now)
+			if tc.ok {
+				if err != nil || id.Subject != "asha" {
+					t.Errorf("Verify = %+v, %v; want asha", id, err)
+				}
+				return
+			}
+			if err != ErrInvalidCredentials {
+				t.Errorf("Verify = %+v, %v; want ErrInvalidCredentials", id, err)
+			}
+		})
+	}
+}
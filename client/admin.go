@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/model"
+)
+
+// AuditQuery filters AuditLog. Zero fields match every entry; Limit zero
+// uses the server default.
+type AuditQuery struct {
+	Actor      string
+	Action     string
+	TargetType string
+	TargetID   int
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+}
+
+func (q AuditQuery) values() url.Values {
+	values := url.Values{}
+	if q.Actor != "" {
+		values.Set("actor", q.Actor)
+	}
+	if q.Action != "" {
+		values.Set("action", q.Action)
+	}
+	if q.TargetType != "" {
+		values.Set("target_type", q.TargetType)
+	}
+	if q.TargetID > 0 {
+		values.Set("target_id", strconv.Itoa(q.TargetID))
+	}
+	if !q.Since.IsZero() {
+		values.Set("since", q.Since.Format(time.RFC3339))
+	}
+	if !q.Until.IsZero() {
+		values.Set("until", q.Until.Format(time.RFC3339))
+	}
+	if q.Limit > 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+	return values
+}
+
+// AuditLog lists audit entries matching query, newest first.
+func (c *Client) AuditLog(ctx context.Context, query AuditQuery) ([]model.AuditEntry, error) {
+	var entries []model.AuditEntry
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/v2/audit", query: query.values()}, &entries)
+	return entries, err
+}
+
+// PurgeTrash permanently removes what has been in the trash longer than the
+// server's retention and returns how many contacts and questions went. With
+// no retention the server empties the whole trash, after the same
+// confirmation step as DeleteAllContacts.
+func (c *Client) PurgeTrash(ctx context.Context) (contacts, questions int64, err error) {
+	var purged struct {
+		Contacts  int64 `json:"contacts_purged"`
+		Questions int64 `json:"questions_purged"`
+	}
+	err = c.doConfirmed(ctx, "purge trash", request{method: http.MethodPost, path: "/api/v2/trash/purge"}, &purged)
+	return purged.Contacts, purged.Questions, err
+}
+
+// Ready asks /readyz whether the server can take traffic. A server that is
+// not ready answers with an *Error, returned along with its report.
+func (c *Client) Ready(ctx context.Context) (*model.HealthReport, error) {
+	var report model.HealthReport
+	err := c.do(ctx, request{method: http.MethodGet, path: "/readyz", once: true}, &report)
+	if err == nil {
+		return &report, nil
+	}
+	var apiErr *Error
+	if errors.As(err, &apiErr) && json.Unmarshal(apiErr.data, &report) == nil && report.Status != "" {
+		return &report, err
+	}
+	return nil, err
+}
@@ -0,0 +1,308 @@
+// Package client is a typed Go client for the mongoapi HTTP API. It speaks
+// the /api/v2 routes and the public quiz routes, unwraps the model.Response
+// envelope into typed values, and turns error answers into *Error.
+//
+//	c, err := client.New(client.Config{BaseURL: "http://localhost:8080", APIKey: key})
+//	contact, err := c.GetContact(ctx, 42)
+//	if errors.Is(err, client.ErrNotFound) {
+//		...
+//	}
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/model"
+)
+
+// Defaults used for zero Config fields.
+const (
+	DefaultTimeout    = 30 * time.Second
+	DefaultMaxRetries = 3
+	DefaultMinBackoff = 200 * time.Millisecond
+	DefaultMaxBackoff = 5 * time.Second
+)
+
+// Config describes where the API lives and how to call it.
+type Config struct {
+	// BaseURL is the scheme and host of the server, such as
+	// http://localhost:8080, optionally with a path prefix.
+	BaseURL string
+	// APIKey is sent as X-API-Key. Token, a JWT, is sent as a bearer token
+	// when there is no APIKey.
+	APIKey string
+	Token  string
+	// HTTPClient defaults to a client with DefaultTimeout.
+	HTTPClient *http.Client
+	// MaxRetries is how many times an idempotent request (GET, PUT, DELETE)
+	// is retried after a network error or a 429, 502, 503 or 504 answer.
+	// Zero uses DefaultMaxRetries; a negative value disables retries.
+	MaxRetries int
+	// MinBackoff and MaxBackoff bound the jittered exponential delay
+	// between retries. A Retry-After header wins when it asks for longer.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// UserAgent is sent with every request when set.
+	UserAgent string
+}
+
+// Client calls the API. It is safe for concurrent use.
+type Client struct {
+	base       *url.URL
+	http       *http.Client
+	apiKey     string
+	token      string
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	userAgent  string
+}
+
+// New returns a client for cfg, filling in defaults.
+func New(cfg Config) (*Client, error) {
+	base, err := url.Parse(strings.TrimSuffix(cfg.BaseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("client: base URL: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" || base.Host == "" {
+		return nil, fmt.Errorf("client: base URL %q must be an absolute http or https URL", cfg.BaseURL)
+	}
+
+	c := &Client{
+		base:       base,
+		http:       cfg.HTTPClient,
+		apiKey:     cfg.APIKey,
+		token:      cfg.Token,
+		maxRetries: cfg.MaxRetries,
+		minBackoff: cfg.MinBackoff,
+		maxBackoff: cfg.MaxBackoff,
+		userAgent:  cfg.UserAgent,
+	}
+	if c.http == nil {
+		c.http = &http.Client{Timeout: DefaultTimeout}
+	}
+	if c.maxRetries == 0 {
+		c.maxRetries = DefaultMaxRetries
+	} else if c.maxRetries < 0 {
+		c.maxRetries = 0
+	}
+	if c.minBackoff <= 0 {
+		c.minBackoff = DefaultMinBackoff
+	}
+	if c.maxBackoff <= 0 {
+		c.maxBackoff = DefaultMaxBackoff
+	}
+	if c.maxBackoff < c.minBackoff {
+		c.maxBackoff = c.minBackoff
+	}
+	return c, nil
+}
+
+// request is one API call.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	// body is encoded as JSON unless raw is set.
+	body        interface{}
+	raw         io.Reader
+	contentType string
+	// once turns retries off for a request that is not idempotent even
+	// though its method is, such as deleting every contact.
+	once bool
+}
+
+// envelope is model.Response with Data left undecoded.
+type envelope struct {
+	Message    string          `json:"message"`
+	StatusCode int             `json:"status"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// retryable statuses are worth repeating an idempotent request for.
+var retryable = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// do sends req and decodes the data of a 2xx answer into out, which may be
+// nil. Error answers become *Error.
+func (c *Client) do(ctx context.Context, req request, out interface{}) error {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return fmt.Errorf("client: decoding %s %s: %w", req.method, req.path, err)
+	}
+	if err := json.Unmarshal(env.Data, out); err != nil {
+		return fmt.Errorf("client: decoding %s %s: %w", req.method, req.path, err)
+	}
+	return nil
+}
+
+// send performs req, retrying when that is safe, and returns a 2xx response
+// for the caller to read and close.
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	var body []byte
+	if req.raw == nil && req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return nil, fmt.Errorf("client: encoding %s %s: %w", req.method, req.path, err)
+		}
+	}
+
+	retries := 0
+	if !req.once && req.raw == nil {
+		switch req.method {
+		case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+			retries = c.maxRetries
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		httpReq, err := c.newRequest(ctx, req, body)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.http.Do(httpReq)
+		if err == nil && resp.StatusCode < 300 {
+			return resp, nil
+		}
+
+		var wait time.Duration
+		if err == nil {
+			apiErr := decodeError(resp)
+			if attempt >= retries || !retryable[resp.StatusCode] {
+				return nil, apiErr
+			}
+			wait = retryAfter(resp)
+			err = apiErr
+		} else if attempt >= retries || ctx.Err() != nil {
+			return nil, err
+		}
+
+		if backoff := c.backoff(attempt); backoff > wait {
+			wait = backoff
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errors.Join(ctx.Err(), err)
+		case <-time.After(wait):
+		}
+	}
+}
+
+// newRequest builds the HTTP request for one attempt at req.
+func (c *Client) newRequest(ctx context.Context, req request, body []byte) (*http.Request, error) {
+	u := *c.base
+	u.Path = c.base.Path + req.path
+	if len(req.query) > 0 {
+		u.RawQuery = req.query.Encode()
+	}
+
+	var reader io.Reader
+	contentType := req.contentType
+	switch {
+	case req.raw != nil:
+		reader = req.raw
+	case body != nil:
+		reader = bytes.NewReader(body)
+		contentType = "application/json"
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), reader)
+	if err != nil {
+		return nil, fmt.Errorf("client: %w", err)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	for name, values := range req.header {
+		httpReq.Header[name] = values
+	}
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	if c.apiKey != "" {
+		httpReq.Header.Set("X-API-Key", c.apiKey)
+	} else if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.userAgent != "" {
+		httpReq.Header.Set("User-Agent", c.userAgent)
+	}
+	return httpReq, nil
+}
+
+// backoff is the jittered delay before retry attempt+1: between half and
+// all of MinBackoff doubled attempt times, capped at MaxBackoff.
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.maxBackoff
+	if attempt < 30 {
+		d = min(c.minBackoff<<attempt, c.maxBackoff)
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// retryAfter reads a Retry-After header given in seconds.
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// decodeError reads and closes an error response. Bodies that are not an
+// envelope, such as the router's plain text 404, become the message.
+func decodeError(resp *http.Response) *Error {
+	defer resp.Body.Close()
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-ID"),
+	}
+
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil || env.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(raw))
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return apiErr
+	}
+
+	apiErr.Message = env.Message
+	apiErr.data = env.Data
+	var fields []model.FieldError
+	if json.Unmarshal(env.Data, &fields) == nil {
+		apiErr.Fields = fields
+	}
+	return apiErr
+}
+
+// idPath joins a collection path and a numeric ID.
+func idPath(collection string, id int, rest ...string) string {
+	return collection + "/" + strconv.Itoa(id) + strings.Join(rest, "")
+}
@@ -0,0 +1,363 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/model"
+)
+
+// step is one canned answer of a scripted server. A zero status drops the
+// connection without answering.
+type step struct {
+	status int
+	header map[string]string
+	body   string
+}
+
+// scripted serves steps in order, repeating the last one, and records the
+// request bodies and queries it received.
+type scripted struct {
+	mu      sync.Mutex
+	steps   []step
+	bodies  []string
+	queries []string
+}
+
+func (s *scripted) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	n := len(s.bodies)
+	s.bodies = append(s.bodies, string(body))
+	s.queries = append(s.queries, r.URL.RawQuery)
+	st := s.steps[min(n, len(s.steps)-1)]
+	s.mu.Unlock()
+
+	if st.status == 0 {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+		return
+	}
+	for k, v := range st.header {
+		w.Header().Set(k, v)
+	}
+	w.WriteHeader(st.status)
+	io.WriteString(w, st.body)
+}
+
+func (s *scripted) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.bodies...)
+}
+
+func newTestClient(t *testing.T, steps []step, cfg Config) (*Client, *scripted) {
+	t.Helper()
+	srv := &scripted{steps: steps}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	cfg.BaseURL = ts.URL
+	if cfg.MinBackoff == 0 {
+		cfg.MinBackoff = time.Millisecond
+	}
+	if cfg.MaxBackoff == 0 {
+		cfg.MaxBackoff = 2 * time.Millisecond
+	}
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, srv
+}
+
+const contactBody = `{"message":"Success","status":200,"data":{"id":7,"contact_name":"Asha","mobile":"9876543210"}}`
+
+var unavailable = step{status: http.StatusServiceUnavailable, body: `{"message":"Service unavailable","status":503,"data":"Error"}`}
+
+func TestRetriesIdempotentRequests(t *testing.T) {
+	c, srv := newTestClient(t, []step{unavailable, {status: http.StatusBadGateway}, {status: 200, body: contactBody}}, Config{})
+
+	contact, err := c.GetContact(context.Background(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contact.ID != 7 || contact.ContactName != "Asha" {
+		t.Errorf("GetContact = %+v", contact)
+	}
+	if n := len(srv.requests()); n != 3 {
+		t.Errorf("server saw %d requests, want 3", n)
+	}
+}
+
+func TestRetryResendsBody(t *testing.T) {
+	question := `{"message":"Success","status":200,"data":{"id":3,"question":"2+2?"}}`
+	c, srv := newTestClient(t, []step{{status: http.StatusTooManyRequests}, {status: 200, body: question}}, Config{})
+
+	if _, err := c.ReplaceQuestion(context.Background(), 3, model.Question{Question: "2+2?"}); err != nil {
+		t.Fatal(err)
+	}
+	bodies := srv.requests()
+	if len(bodies) != 2 || bodies[0] == "" || bodies[0] != bodies[1] {
+		t.Errorf("PUT bodies = %q, want the same body twice", bodies)
+	}
+}
+
+func TestRetriesNetworkErrors(t *testing.T) {
+	c, srv := newTestClient(t, []step{{}, {status: 200, body: contactBody}}, Config{})
+
+	if _, err := c.GetContact(context.Background(), 7); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(srv.requests()); n != 2 {
+		t.Errorf("server saw %d requests, want 2", n)
+	}
+}
+
+func TestGivesUpAfterMaxRetries(t *testing.T) {
+	c, srv := newTestClient(t, []step{unavailable}, Config{MaxRetries: 2})
+
+	_, err := c.GetContact(context.Background(), 7)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || apiErr.Message != "Service unavailable" {
+		t.Fatalf("GetContact error = %v, want the last 503", err)
+	}
+	if !errors.Is(err, ErrServer) {
+		t.Errorf("errors.Is(%v, ErrServer) = false", err)
+	}
+	if n := len(srv.requests()); n != 3 {
+		t.Errorf("server saw %d requests, want 1 plus 2 retries", n)
+	}
+}
+
+func TestDoesNotRetry(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		step step
+		call func(c *Client) error
+	}{
+		{
+			name: "POST",
+			step: unavailable,
+			call: func(c *Client) error {
+				_, err := c.CreateContact(context.Background(), model.Contact{ContactName: "Asha"})
+				return err
+			},
+		},
+		{
+			name: "PATCH",
+			step: unavailable,
+			call: func(c *Client) error {
+				_, err := c.PatchContact(context.Background(), 7, model.ContactPatch{})
+				return err
+			},
+		},
+		{
+			name: "delete all",
+			step: unavailable,
+			call: func(c *Client) error {
+				_, err := c.DeleteAllContacts(context.Background())
+				return err
+			},
+		},
+		{
+			name: "retries disabled",
+			cfg:  Config{MaxRetries: -1},
+			step: unavailable,
+			call: func(c *Client) error {
+				_, err := c.GetContact(context.Background(), 7)
+				return err
+			},
+		},
+		{
+			name: "status not retryable",
+			step: step{status: http.StatusInternalServerError, body: `{"message":"Database error!","status":500,"data":"Error"}`},
+			call: func(c *Client) error {
+				_, err := c.GetContact(context.Background(), 7)
+				return err
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, srv := newTestClient(t, []step{tc.step, {status: 200, body: contactBody}}, tc.cfg)
+			if err := tc.call(c); err == nil {
+				t.Fatal("call succeeded, want the first error")
+			}
+			if n := len(srv.requests()); n != 1 {
+				t.Errorf("server saw %d requests, want 1", n)
+			}
+		})
+	}
+}
+
+func TestConfirms(t *testing.T) {
+	confirm := step{status: http.StatusPreconditionRequired, body: `{"message":"Repeat the request","status":428,"data":{"confirm_token":"123.abc","expires_at":"2026-10-17T12:05:00Z"}}`}
+	tests := []struct {
+		name  string
+		steps []step
+		call  func(c *Client) (int64, error)
+		want  int64
+		// queries are the query strings the server should see, in order
+		queries []string
+	}{
+		{
+			name:    "delete all contacts",
+			steps:   []step{confirm, {status: 200, body: `{"message":"Done","status":200,"data":{"deletedCount":4}}`}},
+			call:    func(c *Client) (int64, error) { return c.DeleteAllContacts(context.Background()) },
+			want:    4,
+			queries: []string{"", "confirm=123.abc"},
+		},
+		{
+			name:  "purge whole trash",
+			steps: []step{confirm, {status: 200, body: `{"message":"Success","status":200,"data":{"contacts_purged":2,"questions_purged":1}}`}},
+			call: func(c *Client) (int64, error) {
+				contacts, questions, err := c.PurgeTrash(context.Background())
+				return contacts + questions, err
+			},
+			want:    3,
+			queries: []string{"", "confirm=123.abc"},
+		},
+		{
+			name:  "purge without confirmation",
+			steps: []step{{status: 200, body: `{"message":"Success","status":200,"data":{"contacts_purged":1,"questions_purged":0}}`}},
+			call: func(c *Client) (int64, error) {
+				contacts, questions, err := c.PurgeTrash(context.Background())
+				return contacts + questions, err
+			},
+			want:    1,
+			queries: []string{""},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, srv := newTestClient(t, tc.steps, Config{})
+			got, err := tc.call(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("count = %d, want %d", got, tc.want)
+			}
+			srv.mu.Lock()
+			defer srv.mu.Unlock()
+			if !slices.Equal(srv.queries, tc.queries) {
+				t.Errorf("queries = %q, want %q", srv.queries, tc.queries)
+			}
+		})
+	}
+}
+
+func TestHonoursRetryAfter(t *testing.T) {
+	limited := step{status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "1"}}
+	c, _ := newTestClient(t, []step{limited, {status: 200, body: contactBody}}, Config{})
+
+	start := time.Now()
+	if _, err := c.GetContact(context.Background(), 7); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want at least the 1s Retry-After", elapsed)
+	}
+}
+
+func TestContextCancelsBackoff(t *testing.T) {
+	c, srv := newTestClient(t, []step{unavailable}, Config{MinBackoff: time.Minute, MaxBackoff: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.GetContact(ctx, 7)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ErrServer) {
+		t.Fatalf("error = %v, want the deadline joined with the 503", err)
+	}
+	if n := len(srv.requests()); n != 1 {
+		t.Errorf("server saw %d requests, want 1", n)
+	}
+}
+
+func TestDecodesErrors(t *testing.T) {
+	fields, _ := json.Marshal(model.Response{
+		Message:    "Validation failed",
+		StatusCode: 400,
+		Data:       []model.FieldError{{Field: "mobile", Message: "must be 10 digits"}},
+	})
+	tests := []struct {
+		name string
+		step step
+		want Error
+		is   error
+	}{
+		{
+			name: "field errors",
+			step: step{status: 400, header: map[string]string{"X-Request-ID": "abc123"}, body: string(fields)},
+			want: Error{StatusCode: 400, Message: "Validation failed", RequestID: "abc123",
+				Fields: []model.FieldError{{Field: "mobile", Message: "must be 10 digits"}}},
+			is: ErrBadRequest,
+		},
+		{
+			name: "plain text",
+			step: step{status: 404, body: "404 page not found\n"},
+			want: Error{StatusCode: 404, Message: "404 page not found"},
+			is:   ErrNotFound,
+		},
+		{
+			name: "empty body",
+			step: step{status: 409},
+			want: Error{StatusCode: 409, Message: "Conflict"},
+			is:   ErrConflict,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, _ := newTestClient(t, []step{tc.step}, Config{})
+			_, err := c.GetContact(context.Background(), 7)
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v, want *Error", err)
+			}
+			got := *apiErr
+			got.data = nil
+			if got.StatusCode != tc.want.StatusCode || got.Message != tc.want.Message ||
+				got.RequestID != tc.want.RequestID || len(got.Fields) != len(tc.want.Fields) ||
+				(len(got.Fields) > 0 && got.Fields[0] != tc.want.Fields[0]) {
+				t.Errorf("error = %+v, want %+v", got, tc.want)
+			}
+			if !errors.Is(err, tc.is) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tc.is)
+			}
+		})
+	}
+}
+
+func TestBackoffBounds(t *testing.T) {
+	c, err := New(Config{BaseURL: "http://localhost", MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		attempt  int
+		lo, high time.Duration
+	}{
+		{0, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond, 400 * time.Millisecond},
+		{4, 500 * time.Millisecond, time.Second},
+		{40, 500 * time.Millisecond, time.Second},
+	} {
+		for range 50 {
+			if d := c.backoff(tc.attempt); d < tc.lo || d > tc.high {
+				t.Fatalf("backoff(%d) = %v, want between %v and %v", tc.attempt, d, tc.lo, tc.high)
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/AniketGodambe/mongoapi/contactio"
+	"github.com/AniketGodambe/mongoapi/model"
+)
+
+const contactsPath = "/api/v2/contacts"
+
+// ContactQuery filters ListContacts. Empty fields match every contact.
+type ContactQuery struct {
+	// Channel is a channel type such as "Email".
+	Channel string
+	// Language is a language name or code such as "hi".
+	Language string
+}
+
+func (q ContactQuery) values() url.Values {
+	values := url.Values{}
+	if q.Channel != "" {
+		values.Set("channel", q.Channel)
+	}
+	if q.Language != "" {
+		values.Set("language", q.Language)
+	}
+	return values
+}
+
+// ListContacts returns the contacts matching query.
+func (c *Client) ListContacts(ctx context.Context, query ContactQuery) ([]model.Contact, error) {
+	var contacts []model.Contact
+	err := c.do(ctx, request{method: http.MethodGet, path: contactsPath, query: query.values()}, &contacts)
+	return contacts, err
+}
+
+// GetContact returns one contact.
+func (c *Client) GetContact(ctx context.Context, id int) (*model.Contact, error) {
+	var contact model.Contact
+	if err := c.do(ctx, request{method: http.MethodGet, path: idPath(contactsPath, id)}, &contact); err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+// CreateContact stores a new contact and returns it with its ID.
+func (c *Client) CreateContact(ctx context.Context, contact model.Contact) (*model.Contact, error) {
+	var created model.Contact
+	if err := c.do(ctx, request{method: http.MethodPost, path: contactsPath, body: contact}, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// PatchContact changes the set fields of patch and returns the updated
+// contact.
+func (c *Client) PatchContact(ctx context.Context, id int, patch model.ContactPatch) (*model.Contact, error) {
+	var updated model.Contact
+	if err := c.do(ctx, request{method: http.MethodPatch, path: idPath(contactsPath, id), body: patch}, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteContact moves a contact to the trash.
+func (c *Client) DeleteContact(ctx context.Context, id int) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: idPath(contactsPath, id)}, nil)
+}
+
+// DeleteAllContacts moves every contact to the trash and returns how many
+// were moved. It asks for the confirm token the server requires and repeats
+// the request with it.
+func (c *Client) DeleteAllContacts(ctx context.Context) (int64, error) {
+	var deleted struct {
+		Count int64 `json:"deletedCount"`
+	}
+	if err := c.doConfirmed(ctx, "delete all contacts", request{method: http.MethodDelete, path: contactsPath}, &deleted); err != nil {
+		return 0, err
+	}
+	return deleted.Count, nil
+}
+
+// doConfirmed sends a destructive request once. When the server answers 428
+// with a confirm token it repeats the request with the token, again only
+// once, since the request is not idempotent.
+func (c *Client) doConfirmed(ctx context.Context, action string, req request, out interface{}) error {
+	req.once = true
+	err := c.do(ctx, req, out)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusPreconditionRequired {
+		return err
+	}
+	var token model.ConfirmToken
+	if err := json.Unmarshal(apiErr.data, &token); err != nil || token.Token == "" {
+		return fmt.Errorf("client: %s: no confirm token in %s", action, apiErr)
+	}
+
+	query := url.Values{"confirm": {token.Token}}
+	for k, v := range req.query {
+		query[k] = v
+	}
+	req.query = query
+	return c.do(ctx, req, out)
+}
+
+// ListContactTrash returns the contacts in the trash.
+func (c *Client) ListContactTrash(ctx context.Context) ([]model.Contact, error) {
+	var contacts []model.Contact
+	err := c.do(ctx, request{method: http.MethodGet, path: contactsPath + "/trash"}, &contacts)
+	return contacts, err
+}
+
+// RestoreContact takes a contact out of the trash and returns it.
+func (c *Client) RestoreContact(ctx context.Context, id int) (*model.Contact, error) {
+	var contact model.Contact
+	if err := c.do(ctx, request{method: http.MethodPost, path: idPath(contactsPath, id, "/restore")}, &contact); err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+// ImportContacts uploads contacts in format, one of contactio.FormatJSON,
+// FormatCSV or FormatVCard. With dryRun set the server only validates them.
+// The report lists the rows that failed; that alone is not an error.
+func (c *Client) ImportContacts(ctx context.Context, format string, r io.Reader, dryRun bool) (*model.ImportReport, error) {
+	contentType, ok := contactio.ContentTypes[format]
+	if !ok {
+		return nil, fmt.Errorf("client: unknown import format %q", format)
+	}
+	req := request{
+		method:      http.MethodPost,
+		path:        contactsPath + "/import",
+		query:       url.Values{"format": {format}, "dry_run": {strconv.FormatBool(dryRun)}},
+		raw:         r,
+		contentType: contentType,
+	}
+	var report model.ImportReport
+	if err := c.do(ctx, req, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ExportContacts writes every contact to w in format, one of
+// contactio.FormatJSON, FormatCSV or FormatVCard.
+func (c *Client) ExportContacts(ctx context.Context, format string, w io.Writer) error {
+	contentType, ok := contactio.ContentTypes[format]
+	if !ok {
+		return fmt.Errorf("client: unknown export format %q", format)
+	}
+	req := request{
+		method: http.MethodGet,
+		path:   contactsPath + "/export",
+		query:  url.Values{"format": {format}},
+		header: http.Header{"Accept": {contentType}},
+	}
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("client: export contacts: %w", err)
+	}
+	return nil
+}
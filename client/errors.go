@@ -0,0 +1,69 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/AniketGodambe/mongoapi/model"
+)
+
+// Errors matched by errors.Is against an *Error, by status code.
+var (
+	ErrBadRequest   = errors.New("client: bad request")
+	ErrUnauthorized = errors.New("client: unauthorized")
+	ErrForbidden    = errors.New("client: forbidden")
+	ErrNotFound     = errors.New("client: not found")
+	ErrConflict     = errors.New("client: conflict")
+	ErrServer       = errors.New("client: server error")
+)
+
+// Error is a non-2xx answer from the API, decoded from its response
+// envelope.
+type Error struct {
+	StatusCode int
+	Message    string
+	// Fields lists the rejected fields when validation failed.
+	Fields []model.FieldError
+	// RequestID is the X-Request-ID the server logged the request under.
+	RequestID string
+
+	// data is the raw envelope data, for the callers that expect more than
+	// an error there, such as a confirm token or a health report.
+	data json.RawMessage
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "mongoapi: %d %s", e.StatusCode, e.Message)
+	for i, f := range e.Fields {
+		if i == 0 {
+			b.WriteString(":")
+		} else {
+			b.WriteString(";")
+		}
+		fmt.Fprintf(&b, " %s %s", f.Field, f.Message)
+	}
+	return b.String()
+}
+
+// Is lets errors.Is match the Err* values by status code.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrServer:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
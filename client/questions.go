@@ -0,0 +1,193 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/AniketGodambe/mongoapi/model"
+)
+
+const questionsPath = "/api/v2/questions"
+
+// Question list sort keys. Prefix one with "-" to sort descending.
+const (
+	SortByID           = "id"
+	SortByCreatedAt    = "created_at"
+	SortByLastModified = "last_modified"
+)
+
+// QuestionQuery selects a page of questions. Zero fields use the server
+// defaults: 50 questions, sorted by ID, hidden or not.
+type QuestionQuery struct {
+	Limit int
+	// Page numbers start at 1. Use either Page or Cursor, the NextCursor of
+	// the previous page.
+	Page   int
+	Cursor string
+	Sort   string
+	Hidden *bool
+	// Search matches the question text.
+	Search     string
+	Category   string
+	Tags       []string
+	Difficulty string
+}
+
+func (q QuestionQuery) values() url.Values {
+	values := url.Values{}
+	if q.Limit > 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Page > 0 {
+		values.Set("page", strconv.Itoa(q.Page))
+	}
+	if q.Cursor != "" {
+		values.Set("cursor", q.Cursor)
+	}
+	if q.Sort != "" {
+		values.Set("sort", q.Sort)
+	}
+	if q.Hidden != nil {
+		values.Set("hidden", strconv.FormatBool(*q.Hidden))
+	}
+	if q.Search != "" {
+		values.Set("q", q.Search)
+	}
+	if q.Category != "" {
+		values.Set("category", q.Category)
+	}
+	if len(q.Tags) > 0 {
+		values.Set("tag", strings.Join(q.Tags, ","))
+	}
+	if q.Difficulty != "" {
+		values.Set("difficulty", q.Difficulty)
+	}
+	return values
+}
+
+// ListQuestions returns one page of questions.
+func (c *Client) ListQuestions(ctx context.Context, query QuestionQuery) (*model.Page[model.Question], error) {
+	var page model.Page[model.Question]
+	if err := c.do(ctx, request{method: http.MethodGet, path: questionsPath, query: query.values()}, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// GetQuestion returns one question, answer key included.
+func (c *Client) GetQuestion(ctx context.Context, id int) (*model.Question, error) {
+	return c.question(ctx, request{method: http.MethodGet, path: idPath(questionsPath, id)})
+}
+
+// CreateQuestion stores a new question and returns it with its ID. A
+// question that fails validation is rejected with an *Error listing the
+// fields.
+func (c *Client) CreateQuestion(ctx context.Context, q model.Question) (*model.Question, error) {
+	return c.question(ctx, request{method: http.MethodPost, path: questionsPath, body: q})
+}
+
+// ReplaceQuestion overwrites a question with q and returns the result.
+func (c *Client) ReplaceQuestion(ctx context.Context, id int, q model.Question) (*model.Question, error) {
+	return c.question(ctx, request{method: http.MethodPut, path: idPath(questionsPath, id), body: q})
+}
+
+// DeleteQuestion moves a question to the trash.
+func (c *Client) DeleteQuestion(ctx context.Context, id int) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: idPath(questionsPath, id)}, nil)
+}
+
+// RestoreQuestion takes a question out of the trash and returns it.
+func (c *Client) RestoreQuestion(ctx context.Context, id int) (*model.Question, error) {
+	return c.question(ctx, request{method: http.MethodPost, path: idPath(questionsPath, id, "/restore")})
+}
+
+// SetQuestionHidden hides a question from quiz takers or shows it again.
+// Unlike the v1 toggle it sets the flag, so it is safe to repeat.
+func (c *Client) SetQuestionHidden(ctx context.Context, id int, hidden bool) error {
+	req := request{
+		method: http.MethodPut,
+		path:   idPath(questionsPath, id, "/visibility"),
+		body:   map[string]bool{"hidden": hidden},
+	}
+	return c.do(ctx, req, nil)
+}
+
+// HideQuestion hides a question from quiz takers.
+func (c *Client) HideQuestion(ctx context.Context, id int) error {
+	return c.SetQuestionHidden(ctx, id, true)
+}
+
+// ShowQuestion makes a hidden question visible to quiz takers again.
+func (c *Client) ShowQuestion(ctx context.Context, id int) error {
+	return c.SetQuestionHidden(ctx, id, false)
+}
+
+// ListQuestionTrash returns the questions in the trash.
+func (c *Client) ListQuestionTrash(ctx context.Context) ([]model.Question, error) {
+	var questions []model.Question
+	err := c.do(ctx, request{method: http.MethodGet, path: questionsPath + "/trash"}, &questions)
+	return questions, err
+}
+
+// QuestionCategories lists the categories in use with their question counts.
+func (c *Client) QuestionCategories(ctx context.Context) ([]model.TermCount, error) {
+	var counts []model.TermCount
+	err := c.do(ctx, request{method: http.MethodGet, path: questionsPath + "/categories"}, &counts)
+	return counts, err
+}
+
+// QuestionTags lists the tags in use with their question counts.
+func (c *Client) QuestionTags(ctx context.Context) ([]model.TermCount, error) {
+	var counts []model.TermCount
+	err := c.do(ctx, request{method: http.MethodGet, path: questionsPath + "/tags"}, &counts)
+	return counts, err
+}
+
+// QuestionRevisions lists a question's revisions, oldest first.
+func (c *Client) QuestionRevisions(ctx context.Context, id int) ([]model.QuestionRevision, error) {
+	var revisions []model.QuestionRevision
+	err := c.do(ctx, request{method: http.MethodGet, path: idPath(questionsPath, id, "/revisions")}, &revisions)
+	return revisions, err
+}
+
+// QuestionRevision returns one revision of a question.
+func (c *Client) QuestionRevision(ctx context.Context, id, rev int) (*model.QuestionRevision, error) {
+	var revision model.QuestionRevision
+	path := idPath(questionsPath, id, "/revisions/", strconv.Itoa(rev))
+	if err := c.do(ctx, request{method: http.MethodGet, path: path}, &revision); err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// DiffQuestionRevisions compares revision from with revision to, or with the
+// latest revision when to is zero.
+func (c *Client) DiffQuestionRevisions(ctx context.Context, id, from, to int) (*model.RevisionDiff, error) {
+	query := url.Values{"from": {strconv.Itoa(from)}}
+	if to > 0 {
+		query.Set("to", strconv.Itoa(to))
+	}
+	var diff model.RevisionDiff
+	if err := c.do(ctx, request{method: http.MethodGet, path: idPath(questionsPath, id, "/revisions/diff"), query: query}, &diff); err != nil {
+		return nil, err
+	}
+	return &diff, nil
+}
+
+// RevertQuestion restores a question to an earlier revision and returns it.
+func (c *Client) RevertQuestion(ctx context.Context, id, rev int) (*model.Question, error) {
+	path := idPath(questionsPath, id, "/revisions/", strconv.Itoa(rev), "/revert")
+	return c.question(ctx, request{method: http.MethodPost, path: path})
+}
+
+// question sends req and decodes the question it answers with.
+func (c *Client) question(ctx context.Context, req request) (*model.Question, error) {
+	var q model.Question
+	if err := c.do(ctx, req, &q); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
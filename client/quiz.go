@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/AniketGodambe/mongoapi/model"
+)
+
+const (
+	quizPath     = "/api/quiz"
+	attemptsPath = quizPath + "/attempts"
+)
+
+// QuizQuestions returns a page of visible questions without their answers.
+// query.Hidden is ignored.
+func (c *Client) QuizQuestions(ctx context.Context, query QuestionQuery) (*model.Page[model.QuizQuestion], error) {
+	query.Hidden = nil
+	var page model.Page[model.QuizQuestion]
+	if err := c.do(ctx, request{method: http.MethodGet, path: quizPath + "/questions", query: query.values()}, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// SubmitQuiz grades answers without recording an attempt.
+func (c *Client) SubmitQuiz(ctx context.Context, submission model.QuizSubmission) (*model.QuizResult, error) {
+	var result model.QuizResult
+	if err := c.do(ctx, request{method: http.MethodPost, path: quizPath + "/submit", body: submission}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// StartAttempt starts an attempt for the authenticated caller on count random
+// visible questions, or the server default when count is zero.
+func (c *Client) StartAttempt(ctx context.Context, count int) (*model.Attempt, error) {
+	body := struct {
+		Count int `json:"count,omitempty"`
+	}{count}
+	return c.attempt(ctx, request{method: http.MethodPost, path: attemptsPath, body: body})
+}
+
+// SubmitAttempt records and grades the answers of an attempt.
+func (c *Client) SubmitAttempt(ctx context.Context, id int, submission model.QuizSubmission) (*model.Attempt, error) {
+	return c.attempt(ctx, request{method: http.MethodPost, path: idPath(attemptsPath, id, "/submit"), body: submission})
+}
+
+// Attempt returns one attempt with its questions.
+func (c *Client) Attempt(ctx context.Context, id int) (*model.Attempt, error) {
+	return c.attempt(ctx, request{method: http.MethodGet, path: idPath(attemptsPath, id)})
+}
+
+// Attempts lists the caller's attempts, newest first. An admin may pass
+// another userID; empty means the caller.
+func (c *Client) Attempts(ctx context.Context, userID string) ([]model.Attempt, error) {
+	query := url.Values{}
+	if userID != "" {
+		query.Set("user_id", userID)
+	}
+	var attempts []model.Attempt
+	err := c.do(ctx, request{method: http.MethodGet, path: attemptsPath, query: query}, &attempts)
+	return attempts, err
+}
+
+// attempt sends req and decodes the attempt it answers with.
+func (c *Client) attempt(ctx context.Context, req request) (*model.Attempt, error) {
+	var attempt model.Attempt
+	if err := c.do(ctx, req, &attempt); err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
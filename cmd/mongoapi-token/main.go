@@ -0,0 +1,43 @@
+// Command mongoapi-token issues a JWT bearer token signed with the server's
+// configured key, for use in the Authorization header.
+//
+//	MONGOAPI_JWT_SECRET=... mongoapi-token -sub alice -role editor -ttl 24h
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/auth"
+	"github.com/AniketGodambe/mongoapi/config"
+)
+
+func main() {
+	configPath := flag.String("config", "", "path to a YAML or JSON config file (default $"+config.EnvConfigFile+")")
+	subject := flag.String("sub", "", "subject (user or service name) the token identifies")
+	role := flag.String("role", string(auth.RoleViewer), "role granted: viewer, editor or admin")
+	ttl := flag.Duration("ttl", 24*time.Hour, "token lifetime")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.Auth.JWTSecret == "" {
+		log.Fatalf("No JWT secret configured (set auth.jwt_secret or $%s)", config.EnvJWTSecret)
+	}
+	if *subject == "" {
+		log.Fatal("-sub is required")
+	}
+	if !auth.Role(*role).Valid() {
+		log.Fatalf("Unknown role %q", *role)
+	}
+
+	token, err := auth.Sign([]byte(cfg.Auth.JWTSecret), auth.Identity{Subject: *subject, Role: auth.Role(*role)}, time.Now(), *ttl)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(token)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/AniketGodambe/mongoapi/client"
+	"github.com/AniketGodambe/mongoapi/contactio"
+	"github.com/AniketGodambe/mongoapi/model"
+)
+
+func init() {
+	commands["contacts list"] = command{"", "list contacts, optionally by channel or language", listContacts}
+	commands["contacts get"] = command{"ID", "show one contact", getContact}
+	commands["contacts create"] = command{"", "create a contact from a JSON or YAML file", createContact}
+	commands["contacts update"] = command{"ID", "change the fields set in a JSON or YAML file", updateContact}
+	commands["contacts delete"] = command{"ID...", "move contacts to the trash", deleteContacts}
+	commands["contacts import"] = command{"FILE", "import a JSON, CSV or vCard file", importContacts}
+	commands["contacts export"] = command{"[FILE]", "export every contact as JSON, CSV or vCard", exportContacts}
+}
+
+var contactHeader = []string{"ID", "NAME", "MOBILE", "AGE", "CHANNELS", "LANGUAGES"}
+
+// printContacts writes contacts one per table row.
+func printContacts(out *output, v interface{}, contacts ...model.Contact) error {
+	return out.print(v, contactHeader, func() [][]string {
+		rows := make([][]string, 0, len(contacts))
+		for _, c := range contacts {
+			channels := make([]string, 0, len(c.PreferredChannel))
+			for _, ch := range c.PreferredChannel {
+				channels = append(channels, ch.ChannelName)
+			}
+			age := ""
+			if c.Age > 0 {
+				age = strconv.Itoa(c.Age)
+			}
+			rows = append(rows, []string{
+				strconv.Itoa(c.ID), c.ContactName, c.Mobile, age,
+				strings.Join(channels, ","), strings.Join(c.PreferredLanguage, ","),
+			})
+		}
+		return rows
+	})
+}
+
+func listContacts(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("contacts list")
+	var query client.ContactQuery
+	fs.StringVar(&query.Channel, "channel", "", "only contacts reachable on this channel, such as Email")
+	fs.StringVar(&query.Language, "language", "", "only contacts speaking this language, such as hi")
+	fs.Parse(args)
+
+	contacts, err := e.api.ListContacts(ctx, query)
+	if err != nil {
+		return err
+	}
+	return printContacts(e.out, contacts, contacts...)
+}
+
+func getContact(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("contacts get")
+	fs.Parse(args)
+	id, err := oneID(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	contact, err := e.api.GetContact(ctx, id)
+	if err != nil {
+		return err
+	}
+	return printContacts(e.out, contact, *contact)
+}
+
+func createContact(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("contacts create")
+	file := fs.String("f", "-", "contact file, or - for standard input")
+	fs.Parse(args)
+
+	var contact model.Contact
+	if err := readDocument(*file, &contact); err != nil {
+		return err
+	}
+	created, err := e.api.CreateContact(ctx, contact)
+	if err != nil {
+		return err
+	}
+	return printContacts(e.out, created, *created)
+}
+
+func updateContact(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("contacts update")
+	file := fs.String("f", "-", "file with the fields to change, or - for standard input")
+	fs.Parse(args)
+	id, err := oneID(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	var patch model.ContactPatch
+	if err := readDocument(*file, &patch); err != nil {
+		return err
+	}
+	updated, err := e.api.PatchContact(ctx, id, patch)
+	if err != nil {
+		return err
+	}
+	return printContacts(e.out, updated, *updated)
+}
+
+func deleteContacts(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("contacts delete")
+	fs.Parse(args)
+	ids, err := parseIDs(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := e.api.DeleteContact(ctx, id); err != nil {
+			return err
+		}
+		status("Moved contact %d to the trash", id)
+	}
+	return nil
+}
+
+func importContacts(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("contacts import")
+	format := fs.String("format", "", "json, csv or vcf (default from the file extension)")
+	dryRun := fs.Bool("dry-run", false, "only validate the file")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("import needs exactly one file, or - for standard input")
+	}
+	path := fs.Arg(0)
+
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	report, err := e.api.ImportContacts(ctx, *format, r, *dryRun)
+	if err != nil {
+		return err
+	}
+	verb := "Imported"
+	if report.DryRun {
+		verb = "Would import"
+	}
+	status("%s %d of %d contacts, %d failed", verb, report.Imported, report.Total, report.Failed)
+	err = e.out.print(report, []string{"ROW", "MOBILE", "ERROR"}, func() [][]string {
+		rows := make([][]string, 0, len(report.Errors))
+		for _, rowErr := range report.Errors {
+			rows = append(rows, []string{strconv.Itoa(rowErr.Row), rowErr.Mobile, rowErr.Message})
+		}
+		return rows
+	})
+	if err == nil && report.Failed > 0 {
+		err = errors.New("some rows were not imported")
+	}
+	return err
+}
+
+func exportContacts(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("contacts export")
+	format := fs.String("format", "", "json, csv or vcf (default from the file extension, else json)")
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		return errors.New("export takes at most one file")
+	}
+
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(fs.Arg(0)), ".")
+		if _, ok := contactio.ContentTypes[*format]; !ok {
+			*format = contactio.FormatJSON
+		}
+	}
+	if fs.NArg() == 0 || fs.Arg(0) == "-" {
+		return e.api.ExportContacts(ctx, *format, os.Stdout)
+	}
+
+	f, err := os.Create(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := e.api.ExportContacts(ctx, *format, f); err != nil {
+		f.Close()
+		os.Remove(fs.Arg(0))
+		return err
+	}
+	return f.Close()
+}
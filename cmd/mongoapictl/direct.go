@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/user"
+
+	"github.com/AniketGodambe/mongoapi/auth"
+	"github.com/AniketGodambe/mongoapi/client"
+	"github.com/AniketGodambe/mongoapi/config"
+	"github.com/AniketGodambe/mongoapi/logging"
+	"github.com/AniketGodambe/mongoapi/metrics"
+	"github.com/AniketGodambe/mongoapi/router"
+	"github.com/AniketGodambe/mongoapi/store"
+)
+
+// directBaseURL is the host requests are addressed to in-process. They never
+// reach the network.
+const directBaseURL = "http://mongoapictl.invalid"
+
+// directClient opens the MongoDB store from the config at path and returns a
+// client whose requests are served in-process by the API's own router, as an
+// admin named after the local user. The returned func disconnects.
+func directClient(path string) (*client.Client, func(), error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.Store != config.StoreMongo {
+		return nil, nil, fmt.Errorf("store %q keeps nothing between runs; use -server to reach a running server", cfg.Store)
+	}
+
+	db, err := store.InitDB(cfg.Mongo)
+	if err != nil {
+		return nil, nil, err
+	}
+	disconnect := func() { db.Client().Disconnect(context.Background()) }
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		disconnect()
+		return nil, nil, err
+	}
+	apiKey := hex.EncodeToString(key)
+	authenticator := auth.NewAuthenticator("", []auth.APIKey{{Key: apiKey, Subject: actor(), Role: auth.RoleAdmin}})
+
+	// Only errors are worth showing; access lines would bury the output.
+	logger, err := logging.New(os.Stderr, "error", logging.FormatText)
+	if err != nil {
+		disconnect()
+		return nil, nil, err
+	}
+	handler := router.Router(store.NewMongoStores(db, cfg.Mongo), authenticator, cfg.Retention(), metrics.NewRegistry(), logger)
+
+	api, err := client.New(client.Config{
+		BaseURL:    directBaseURL,
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{Transport: handlerTransport{handler}},
+		MaxRetries: -1,
+	})
+	if err != nil {
+		disconnect()
+		return nil, nil, err
+	}
+	return api, disconnect, nil
+}
+
+// actor names the local user in the audit log.
+func actor() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return "mongoapictl:" + u.Username
+	}
+	return "mongoapictl"
+}
+
+// handlerTransport serves requests with a handler instead of sending them.
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t handlerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, r)
+	return rec.Result(), nil
+}
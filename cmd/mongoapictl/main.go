@@ -0,0 +1,171 @@
+// Command mongoapictl administers contacts and questions from the shell.
+//
+// With -server it calls a running API over HTTP, authenticating with -api-key
+// or -token. Without it, it opens the store named in the config file and
+// serves the same API in-process, so changes are validated, audited and
+// revisioned exactly as they are through the server.
+//
+//	mongoapictl -server http://localhost:8080 -api-key $KEY questions list -hidden true
+//	mongoapictl -config prod.yaml -o yaml contacts get 42
+//	mongoapictl contacts export -format csv contacts.csv
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/client"
+	"github.com/AniketGodambe/mongoapi/config"
+)
+
+// Environment variables read for the global flags' defaults.
+const (
+	envServer = "MONGOAPI_SERVER"
+	envAPIKey = "MONGOAPI_API_KEY"
+	envToken  = "MONGOAPI_TOKEN"
+)
+
+// command is one "resource action" pair.
+type command struct {
+	args    string
+	summary string
+	run     func(ctx context.Context, e *env, args []string) error
+}
+
+// env is what every command runs against.
+type env struct {
+	api *client.Client
+	out *output
+}
+
+var commands = map[string]command{}
+
+func main() {
+	configPath := flag.String("config", "", "config file for direct store access (default $"+config.EnvConfigFile+")")
+	server := flag.String("server", os.Getenv(envServer), "base URL of a running server; direct store access when empty (default $"+envServer+")")
+	apiKey := flag.String("api-key", os.Getenv(envAPIKey), "API key sent to -server (default $"+envAPIKey+")")
+	token := flag.String("token", os.Getenv(envToken), "JWT bearer token sent to -server when there is no API key (default $"+envToken+")")
+	format := flag.String("o", formatTable, "output format: table, json or yaml")
+	timeout := flag.Duration("timeout", time.Minute, "how long the whole command may take")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flag.Arg(0)+" "+flag.Arg(1)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", flag.Arg(0)+" "+flag.Arg(1))
+		usage()
+		os.Exit(2)
+	}
+	out, err := newOutput(os.Stdout, *format)
+	if err != nil {
+		fail(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	err = run(ctx, cmd, out, client.Config{
+		BaseURL:   *server,
+		APIKey:    *apiKey,
+		Token:     *token,
+		UserAgent: "mongoapictl",
+	}, *configPath)
+	cancel()
+	if err != nil {
+		fail(err)
+	}
+}
+
+// run connects to the server, or to the store when there is no server, and
+// runs cmd.
+func run(ctx context.Context, cmd command, out *output, cfg client.Config, configPath string) error {
+	var api *client.Client
+	var err error
+	if cfg.BaseURL != "" {
+		api, err = client.New(cfg)
+	} else {
+		var closeStore func()
+		api, closeStore, err = directClient(configPath)
+		if closeStore != nil {
+			defer closeStore()
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return cmd.run(ctx, &env{api: api, out: out}, flag.Args()[2:])
+}
+
+// fail reports err, with the request ID of an API error, and exits.
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "Error:", err)
+	var apiErr *client.Error
+	if errors.As(err, &apiErr) && apiErr.RequestID != "" {
+		fmt.Fprintln(os.Stderr, "Request ID:", apiErr.RequestID)
+	}
+	os.Exit(1)
+}
+
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintln(w, "Usage: mongoapictl [global flags] <resource> <action> [flags] [args]")
+	fmt.Fprintln(w, "\nCommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cmd := commands[name]
+		fmt.Fprintf(w, "  %-40s %s\n", strings.TrimSpace(name+" "+cmd.args), cmd.summary)
+	}
+	fmt.Fprintln(w, "\nRun a command with -h for its flags. Global flags:")
+	flag.PrintDefaults()
+}
+
+// newFlags returns the flag set for a command, printing its usage line on -h.
+func newFlags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: mongoapictl %s [flags] %s\n", name, commands[name].args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseIDs reads one or more numeric IDs.
+func parseIDs(args []string) ([]int, error) {
+	if len(args) == 0 {
+		return nil, errors.New("no ID given")
+	}
+	ids := make([]int, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil || id < 1 {
+			return nil, fmt.Errorf("invalid ID %q", arg)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// oneID reads exactly one numeric ID.
+func oneID(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, errors.New("expected exactly one ID")
+	}
+	ids, err := parseIDs(args)
+	if err != nil {
+		return 0, err
+	}
+	return ids[0], nil
+}
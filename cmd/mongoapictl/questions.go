@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/AniketGodambe/mongoapi/client"
+	"github.com/AniketGodambe/mongoapi/model"
+)
+
+func init() {
+	commands["questions list"] = command{"", "list a page of questions, or all of them with -all", listQuestions}
+	commands["questions get"] = command{"ID", "show one question with its answer", getQuestion}
+	commands["questions create"] = command{"", "create a question from a JSON or YAML file", createQuestion}
+	commands["questions update"] = command{"ID", "replace a question with a JSON or YAML file", updateQuestion}
+	commands["questions delete"] = command{"ID...", "move questions to the trash", deleteQuestions}
+	commands["questions hide"] = command{"ID...", "hide questions from quiz takers", hideQuestions}
+	commands["questions show"] = command{"ID...", "make hidden questions visible again", showQuestions}
+	commands["questions import"] = command{"FILE", "create every question in a JSON or YAML list", importQuestions}
+	commands["questions export"] = command{"[FILE]", "write every question as a JSON or YAML list", exportQuestions}
+}
+
+// maxQuestionText is how much of a question the table shows.
+const maxQuestionText = 60
+
+var questionHeader = []string{"ID", "TYPE", "HIDDEN", "CATEGORY", "DIFFICULTY", "QUESTION"}
+
+// printQuestions writes questions one per table row.
+func printQuestions(out *output, v interface{}, questions ...model.Question) error {
+	return out.print(v, questionHeader, func() [][]string {
+		rows := make([][]string, 0, len(questions))
+		for _, q := range questions {
+			text := []rune(q.Question)
+			if len(text) > maxQuestionText {
+				text = append(text[:maxQuestionText-3], []rune("...")...)
+			}
+			rows = append(rows, []string{
+				strconv.Itoa(q.ID), q.Kind(), strconv.FormatBool(q.Hidden),
+				q.Category, q.Difficulty, string(text),
+			})
+		}
+		return rows
+	})
+}
+
+// allQuestions follows the list cursor until every question matching query
+// has been read.
+func allQuestions(ctx context.Context, api *client.Client, query client.QuestionQuery) ([]model.Question, error) {
+	query.Page = 0
+	var questions []model.Question
+	for {
+		page, err := api.ListQuestions(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		questions = append(questions, page.Items...)
+		if page.NextCursor == "" {
+			return questions, nil
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+func listQuestions(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("questions list")
+	var query client.QuestionQuery
+	fs.IntVar(&query.Limit, "limit", 0, "questions per page (default 50)")
+	fs.IntVar(&query.Page, "page", 0, "page number, from 1")
+	fs.StringVar(&query.Cursor, "cursor", "", "next_cursor of the previous page")
+	fs.StringVar(&query.Sort, "sort", "", "id, created_at or last_modified; prefix - for descending")
+	hidden := fs.String("hidden", "", "true or false to list only hidden or visible questions")
+	fs.StringVar(&query.Search, "q", "", "search the question text")
+	fs.StringVar(&query.Category, "category", "", "only this category")
+	tags := fs.String("tag", "", "only questions with all these comma separated tags")
+	fs.StringVar(&query.Difficulty, "difficulty", "", "easy, medium or hard")
+	all := fs.Bool("all", false, "read every page")
+	fs.Parse(args)
+
+	if *hidden != "" {
+		h, err := strconv.ParseBool(*hidden)
+		if err != nil {
+			return errors.New("-hidden must be true or false")
+		}
+		query.Hidden = &h
+	}
+	if *tags != "" {
+		query.Tags = strings.Split(*tags, ",")
+	}
+
+	if *all {
+		questions, err := allQuestions(ctx, e.api, query)
+		if err != nil {
+			return err
+		}
+		return printQuestions(e.out, questions, questions...)
+	}
+
+	page, err := e.api.ListQuestions(ctx, query)
+	if err != nil {
+		return err
+	}
+	if page.NextCursor != "" {
+		status("Showing %d of %d; next page: -cursor %s", len(page.Items), page.Total, page.NextCursor)
+	}
+	return printQuestions(e.out, page.Items, page.Items...)
+}
+
+func getQuestion(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("questions get")
+	fs.Parse(args)
+	id, err := oneID(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	question, err := e.api.GetQuestion(ctx, id)
+	if err != nil {
+		return err
+	}
+	return printQuestions(e.out, question, *question)
+}
+
+func createQuestion(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("questions create")
+	file := fs.String("f", "-", "question file, or - for standard input")
+	fs.Parse(args)
+
+	var question model.Question
+	if err := readDocument(*file, &question); err != nil {
+		return err
+	}
+	created, err := e.api.CreateQuestion(ctx, question)
+	if err != nil {
+		return err
+	}
+	return printQuestions(e.out, created, *created)
+}
+
+func updateQuestion(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("questions update")
+	file := fs.String("f", "-", "question file, or - for standard input")
+	fs.Parse(args)
+	id, err := oneID(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	var question model.Question
+	if err := readDocument(*file, &question); err != nil {
+		return err
+	}
+	updated, err := e.api.ReplaceQuestion(ctx, id, question)
+	if err != nil {
+		return err
+	}
+	return printQuestions(e.out, updated, *updated)
+}
+
+func deleteQuestions(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("questions delete")
+	fs.Parse(args)
+	ids, err := parseIDs(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := e.api.DeleteQuestion(ctx, id); err != nil {
+			return err
+		}
+		status("Moved question %d to the trash", id)
+	}
+	return nil
+}
+
+func hideQuestions(ctx context.Context, e *env, args []string) error {
+	return setQuestionsHidden(ctx, e, "questions hide", args, true)
+}
+
+func showQuestions(ctx context.Context, e *env, args []string) error {
+	return setQuestionsHidden(ctx, e, "questions show", args, false)
+}
+
+func setQuestionsHidden(ctx context.Context, e *env, name string, args []string, hidden bool) error {
+	fs := newFlags(name)
+	fs.Parse(args)
+	ids, err := parseIDs(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := e.api.SetQuestionHidden(ctx, id, hidden); err != nil {
+			return err
+		}
+		if hidden {
+			status("Hid question %d", id)
+		} else {
+			status("Showed question %d", id)
+		}
+	}
+	return nil
+}
+
+func importQuestions(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("questions import")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("import needs exactly one file, or - for standard input")
+	}
+
+	var questions []model.Question
+	if err := readDocument(fs.Arg(0), &questions); err != nil {
+		return err
+	}
+
+	type result struct {
+		Row   int    `json:"row"`
+		ID    int    `json:"id,omitempty"`
+		Error string `json:"error,omitempty"`
+	}
+	results := make([]result, 0, len(questions))
+	failed := 0
+	for i, q := range questions {
+		q.ID = 0
+		r := result{Row: i + 1}
+		created, err := e.api.CreateQuestion(ctx, q)
+		var apiErr *client.Error
+		switch {
+		case err == nil:
+			r.ID = created.ID
+		case errors.As(err, &apiErr) && apiErr.StatusCode < 500:
+			r.Error = err.Error()
+			failed++
+		default:
+			return fmt.Errorf("row %d: %w", i+1, err)
+		}
+		results = append(results, r)
+	}
+
+	status("Imported %d of %d questions, %d failed", len(questions)-failed, len(questions), failed)
+	err := e.out.print(results, []string{"ROW", "ID", "ERROR"}, func() [][]string {
+		rows := make([][]string, 0, len(results))
+		for _, r := range results {
+			id := ""
+			if r.ID > 0 {
+				id = strconv.Itoa(r.ID)
+			}
+			rows = append(rows, []string{strconv.Itoa(r.Row), id, r.Error})
+		}
+		return rows
+	})
+	if err == nil && failed > 0 {
+		err = errors.New("some questions were not imported")
+	}
+	return err
+}
+
+func exportQuestions(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("questions export")
+	format := fs.String("format", "", "json or yaml (default from the file extension, else json)")
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		return errors.New("export takes at most one file")
+	}
+
+	if *format == "" {
+		switch filepath.Ext(fs.Arg(0)) {
+		case ".yaml", ".yml":
+			*format = formatYAML
+		default:
+			*format = formatJSON
+		}
+	}
+	if *format != formatJSON && *format != formatYAML {
+		return fmt.Errorf("-format must be json or yaml, got %q", *format)
+	}
+
+	questions, err := allQuestions(ctx, e.api, client.QuestionQuery{})
+	if err != nil {
+		return err
+	}
+	if questions == nil {
+		questions = []model.Question{}
+	}
+
+	var w io.Writer = os.Stdout
+	if fs.NArg() == 1 && fs.Arg(0) != "-" {
+		f, err := os.Create(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if *format == formatYAML {
+		err = writeYAML(w, questions)
+	} else {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(questions)
+	}
+	if err != nil {
+		return err
+	}
+	status("Exported %d questions", len(questions))
+	return nil
+}
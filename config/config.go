@@ -0,0 +1,365 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/auth"
+	"github.com/AniketGodambe/mongoapi/logging"
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables read by Load. Values set here win over the config file.
+const (
+	EnvConfigFile          = "MONGOAPI_CONFIG"
+	EnvAddr                = "MONGOAPI_ADDR"
+	EnvStore               = "MONGOAPI_STORE"
+	EnvMongoURI            = "MONGOAPI_MONGO_URI"
+	EnvMongoDatabase       = "MONGOAPI_MONGO_DATABASE"
+	EnvContactsCollection  = "MONGOAPI_CONTACTS_COLLECTION"
+	EnvQuestionsCollection = "MONGOAPI_QUESTIONS_COLLECTION"
+	EnvAttemptsCollection  = "MONGOAPI_ATTEMPTS_COLLECTION"
+	EnvRevisionsCollection = "MONGOAPI_REVISIONS_COLLECTION"
+	EnvAuditCollection     = "MONGOAPI_AUDIT_COLLECTION"
+	EnvCountersCollection  = "MONGOAPI_COUNTERS_COLLECTION"
+	EnvJWTSecret           = "MONGOAPI_JWT_SECRET"
+	EnvTrashRetention      = "MONGOAPI_TRASH_RETENTION"
+	EnvLogLevel            = "MONGOAPI_LOG_LEVEL"
+	EnvLogFormat           = "MONGOAPI_LOG_FORMAT"
+	// EnvAPIKeys holds comma separated key:subject:role triples.
+	EnvAPIKeys = "MONGOAPI_API_KEYS"
+)
+
+// minSecretLen is the shortest accepted HS256 signing key, in bytes.
+const minSecretLen = 32
+
+const redacted = "xxxxx"
+
+// Storage backends accepted in Config.Store.
+const (
+	StoreMongo  = "mongo"
+	StoreMemory = "memory"
+)
+
+// Config is the runtime configuration of the API server.
+type Config struct {
+	Addr  string      `json:"addr" yaml:"addr"`
+	Store string      `json:"store" yaml:"store"`
+	Mongo MongoConfig `json:"mongo" yaml:"mongo"`
+	Auth  AuthConfig  `json:"auth" yaml:"auth"`
+	Log   LogConfig   `json:"log" yaml:"log"`
+	// TrashRetention is how long deleted contacts and questions can be
+	// restored before they are purged, as a Go duration. "0" keeps them
+	// until purged by hand.
+	TrashRetention string `json:"trash_retention" yaml:"trash_retention"`
+}
+
+// MongoConfig describes where the MongoDB collections live.
+type MongoConfig struct {
+	URI                 string `json:"uri" yaml:"uri"`
+	Database            string `json:"database" yaml:"database"`
+	ContactsCollection  string `json:"contacts_collection" yaml:"contacts_collection"`
+	QuestionsCollection string `json:"questions_collection" yaml:"questions_collection"`
+	AttemptsCollection  string `json:"attempts_collection" yaml:"attempts_collection"`
+	RevisionsCollection string `json:"revisions_collection" yaml:"revisions_collection"`
+	AuditCollection     string `json:"audit_collection" yaml:"audit_collection"`
+	CountersCollection  string `json:"counters_collection" yaml:"counters_collection"`
+}
+
+// LogConfig selects how much is logged and how.
+type LogConfig struct {
+	// Level is debug, info, warn or error.
+	Level string `json:"level" yaml:"level"`
+	// Format is text or json.
+	Format string `json:"format" yaml:"format"`
+}
+
+// AuthConfig lists the credentials the API accepts.
+type AuthConfig struct {
+	JWTSecret string         `json:"jwt_secret" yaml:"jwt_secret"`
+	APIKeys   []APIKeyConfig `json:"api_keys" yaml:"api_keys"`
+}
+
+type APIKeyConfig struct {
+	Key     string `json:"key" yaml:"key"`
+	Subject string `json:"subject" yaml:"subject"`
+	Role    string `json:"role" yaml:"role"`
+}
+
+// Default returns the configuration used when nothing else is set.
+func Default() Config {
+	return Config{
+		Addr:           ":8080",
+		Store:          StoreMongo,
+		TrashRetention: "720h",
+		Log:            LogConfig{Level: "info", Format: logging.FormatText},
+		Mongo: MongoConfig{
+			URI:                 "mongodb://localhost:27017",
+			Database:            "contactdb",
+			ContactsCollection:  "contacts",
+			QuestionsCollection: "questions",
+			AttemptsCollection:  "attempts",
+			RevisionsCollection: "question_revisions",
+			AuditCollection:     "audit_log",
+			CountersCollection:  "counters",
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, then the optional file at
+// path (or $MONGOAPI_CONFIG when path is empty), then environment variables,
+// and validates the result.
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	if path == "" {
+		path = os.Getenv(EnvConfigFile)
+	}
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// loadFile decodes a YAML or JSON file over cfg, picking the format by extension.
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: reading %s: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".json":
+		err = json.Unmarshal(data, cfg)
+	default:
+		return fmt.Errorf("config: unsupported file type %q (want .yaml, .yml or .json)", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("config: parsing %s: %w", path, err)
+	}
+	return nil
+}
+
+func applyEnv(cfg *Config) error {
+	setFromEnv(&cfg.Addr, EnvAddr)
+	setFromEnv(&cfg.Store, EnvStore)
+	setFromEnv(&cfg.Mongo.URI, EnvMongoURI)
+	setFromEnv(&cfg.Mongo.Database, EnvMongoDatabase)
+	setFromEnv(&cfg.Mongo.ContactsCollection, EnvContactsCollection)
+	setFromEnv(&cfg.Mongo.QuestionsCollection, EnvQuestionsCollection)
+	setFromEnv(&cfg.Mongo.AttemptsCollection, EnvAttemptsCollection)
+	setFromEnv(&cfg.Mongo.RevisionsCollection, EnvRevisionsCollection)
+	setFromEnv(&cfg.Mongo.AuditCollection, EnvAuditCollection)
+	setFromEnv(&cfg.Mongo.CountersCollection, EnvCountersCollection)
+	setFromEnv(&cfg.Auth.JWTSecret, EnvJWTSecret)
+	setFromEnv(&cfg.TrashRetention, EnvTrashRetention)
+	setFromEnv(&cfg.Log.Level, EnvLogLevel)
+	setFromEnv(&cfg.Log.Format, EnvLogFormat)
+
+	if v := os.Getenv(EnvAPIKeys); v != "" {
+		cfg.Auth.APIKeys = nil
+		for _, entry := range strings.Split(v, ",") {
+			parts := strings.Split(strings.TrimSpace(entry), ":")
+			if len(parts) != 3 {
+				return fmt.Errorf("config: %s: entries must look like key:subject:role", EnvAPIKeys)
+			}
+			cfg.Auth.APIKeys = append(cfg.Auth.APIKeys, APIKeyConfig{Key: parts[0], Subject: parts[1], Role: parts[2]})
+		}
+	}
+	return nil
+}
+
+func setFromEnv(dst *string, key string) {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		*dst = v
+	}
+}
+
+// Validate reports every problem with the configuration at once.
+func (c Config) Validate() error {
+	var errs []error
+
+	if _, _, err := net.SplitHostPort(c.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("addr %q: %w", c.Addr, err))
+	}
+
+	switch c.Store {
+	case StoreMemory:
+	case StoreMongo:
+		errs = append(errs, c.Mongo.validate()...)
+	default:
+		errs = append(errs, fmt.Errorf("store %q: must be %q or %q", c.Store, StoreMongo, StoreMemory))
+	}
+
+	errs = append(errs, c.Auth.validate()...)
+
+	if d, err := time.ParseDuration(c.TrashRetention); err != nil || d < 0 {
+		errs = append(errs, fmt.Errorf("trash_retention %q: must be a non-negative duration such as 720h", c.TrashRetention))
+	}
+
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level %q: must be debug, info, warn or error", c.Log.Level))
+	}
+	if f := strings.ToLower(c.Log.Format); f != logging.FormatText && f != logging.FormatJSON {
+		errs = append(errs, fmt.Errorf("log.format %q: must be %q or %q", c.Log.Format, logging.FormatText, logging.FormatJSON))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("config: invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Retention returns TrashRetention as a duration, 0 if it does not parse.
+func (c Config) Retention() time.Duration {
+	d, _ := time.ParseDuration(c.TrashRetention)
+	return d
+}
+
+func (m MongoConfig) validate() []error {
+	var errs []error
+
+	u, err := url.Parse(m.URI)
+	if err != nil || (u.Scheme != "mongodb" && u.Scheme != "mongodb+srv") {
+		errs = append(errs, fmt.Errorf("mongo.uri %q: must be a mongodb:// or mongodb+srv:// URI", redactURI(m.URI)))
+	}
+	if m.Database == "" {
+		errs = append(errs, errors.New("mongo.database: must not be empty"))
+	}
+
+	seen := map[string]string{}
+	for _, coll := range []struct{ key, name string }{
+		{"mongo.contacts_collection", m.ContactsCollection},
+		{"mongo.questions_collection", m.QuestionsCollection},
+		{"mongo.attempts_collection", m.AttemptsCollection},
+		{"mongo.revisions_collection", m.RevisionsCollection},
+		{"mongo.audit_collection", m.AuditCollection},
+		{"mongo.counters_collection", m.CountersCollection},
+	} {
+		if coll.name == "" {
+			errs = append(errs, fmt.Errorf("%s: must not be empty", coll.key))
+			continue
+		}
+		if other, ok := seen[coll.name]; ok {
+			errs = append(errs, fmt.Errorf("%s: %q is already used by %s", coll.key, coll.name, other))
+		}
+		seen[coll.name] = coll.key
+	}
+	return errs
+}
+
+func (a AuthConfig) validate() []error {
+	var errs []error
+
+	if a.JWTSecret != "" && len(a.JWTSecret) < minSecretLen {
+		errs = append(errs, fmt.Errorf("auth.jwt_secret: must be at least %d bytes", minSecretLen))
+	}
+	seen := map[string]bool{}
+	for i, k := range a.APIKeys {
+		if len(k.Key) < 16 {
+			errs = append(errs, fmt.Errorf("auth.api_keys[%d].key: must be at least 16 characters", i))
+		}
+		if seen[k.Key] {
+			errs = append(errs, fmt.Errorf("auth.api_keys[%d].key: duplicate key", i))
+		}
+		seen[k.Key] = true
+		if k.Subject == "" {
+			errs = append(errs, fmt.Errorf("auth.api_keys[%d].subject: must not be empty", i))
+		}
+		if !auth.Role(k.Role).Valid() {
+			errs = append(errs, fmt.Errorf("auth.api_keys[%d].role %q: must be admin, editor or viewer", i, k.Role))
+		}
+	}
+	return errs
+}
+
+// Redacted returns a copy of the configuration that is safe to log.
+func (c Config) Redacted() Config {
+	c.Mongo.URI = redactURI(c.Mongo.URI)
+	if c.Auth.JWTSecret != "" {
+		c.Auth.JWTSecret = redacted
+	}
+	keys := make([]APIKeyConfig, len(c.Auth.APIKeys))
+	for i, k := range c.Auth.APIKeys {
+		k.Key = redacted
+		keys[i] = k
+	}
+	c.Auth.APIKeys = keys
+	return c
+}
+
+// Keys converts the configured API keys for the authenticator.
+func (a AuthConfig) Keys() []auth.APIKey {
+	keys := make([]auth.APIKey, 0, len(a.APIKeys))
+	for _, k := range a.APIKeys {
+		keys = append(keys, auth.APIKey{Key: k.Key, Subject: k.Subject, Role: auth.Role(k.Role)})
+	}
+	return keys
+}
+
+// String renders the redacted configuration, so printing a Config never leaks secrets.
+func (c Config) String() string {
+	out, err := json.Marshal(c.Redacted())
+	if err != nil {
+		return "<unprintable config>"
+	}
+	return string(out)
+}
+
+// secretOptions are connection string options whose values are secrets,
+// lower cased. Any option naming a password is masked as well.
+var secretOptions = map[string]bool{
+	"authmechanismproperties": true,
+	"secret":                  true,
+	"token":                   true,
+}
+
+// redactURI masks the password of a connection string and the values of
+// options that carry secrets, such as tlsCertificateKeyFilePassword.
+func redactURI(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "<unparseable>"
+	}
+	if u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+	}
+	if u.RawQuery != "" {
+		// Rebuilt by hand to keep the options in their original order
+		options := strings.Split(u.RawQuery, "&")
+		for i, option := range options {
+			key, _, _ := strings.Cut(option, "=")
+			name, err := url.QueryUnescape(key)
+			if err != nil {
+				name = key
+			}
+			name = strings.ToLower(name)
+			if secretOptions[name] || strings.Contains(name, "password") {
+				options[i] = key + "=" + redacted
+			}
+		}
+		u.RawQuery = strings.Join(options, "&")
+	}
+	return u.String()
+}
@@ -0,0 +1,414 @@
+// Package contactio reads and writes contacts as JSON, CSV and vCard.
+package contactio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/AniketGodambe/mongoapi/model"
+)
+
+// Supported formats
+const (
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+	FormatVCard = "vcf"
+)
+
+// ContentTypes maps each format to its MIME type.
+var ContentTypes = map[string]string{
+	FormatJSON:  "application/json",
+	FormatCSV:   "text/csv",
+	FormatVCard: "text/vcard",
+}
+
+// FormatFromContentType picks the format for a request Content-Type, if known.
+func FormatFromContentType(contentType string) (string, bool) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+	for format, ct := range ContentTypes {
+		if ct == mediaType {
+			return format, true
+		}
+	}
+	if mediaType == "text/x-vcard" {
+		return FormatVCard, true
+	}
+	return "", false
+}
+
+// CSVHeader is the column order written by the CSV encoder. The decoder
+// accepts these columns in any order; only mobile is required. Lists are
+// separated by semicolons and channels are written as type:details, e.g.
+// "Email:a@example.com;Phone:123-456-7890". Text cells that a spreadsheet
+// would run as a formula are written with a leading apostrophe, which the
+// decoder removes again.
+var CSVHeader = []string{"id", "contact_name", "age", "mobile", "preferred_channel", "preferred_language"}
+
+// Record is one decoded contact. Row is its 1-based position in the input
+// (the data row for CSV, the element for JSON, the card for vCard).
+type Record struct {
+	Row     int
+	Contact model.Contact
+	// Err is set when the row could not be parsed; Contact is then partial.
+	Err error
+}
+
+// Decode reads every contact from r. A malformed document fails as a whole;
+// a malformed row only sets that Record's Err.
+func Decode(format string, r io.Reader) ([]Record, error) {
+	switch format {
+	case FormatJSON:
+		return decodeJSON(r)
+	case FormatCSV:
+		return decodeCSV(r)
+	case FormatVCard:
+		return decodeVCard(r)
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+func decodeJSON(r io.Reader) ([]Record, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("expected a JSON array of contacts: %w", err)
+	}
+
+	records := make([]Record, 0, len(raw))
+	for i, item := range raw {
+		rec := Record{Row: i + 1}
+		rec.Err = json.Unmarshal(item, &rec.Contact)
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func decodeCSV(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["mobile"]; !ok {
+		return nil, errors.New("CSV header must include a mobile column")
+	}
+
+	var records []Record
+	for row := 1; ; row++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		rec := Record{Row: row}
+		if err != nil {
+			rec.Err = err
+			records = append(records, rec)
+			continue
+		}
+
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(fields) {
+				return unescapeCell(strings.TrimSpace(fields[i]))
+			}
+			return ""
+		}
+		rec.Contact.ContactName = get("contact_name")
+		rec.Contact.Mobile = get("mobile")
+		if age := get("age"); age != "" {
+			rec.Contact.Age, rec.Err = strconv.Atoi(age)
+			if rec.Err != nil {
+				rec.Err = fmt.Errorf("age %q is not a number", age)
+			}
+		}
+		for _, item := range splitList(get("preferred_channel")) {
+			name, details, ok := strings.Cut(item, ":")
+			if !ok {
+				rec.Err = fmt.Errorf("channel %q must look like type:details", item)
+				break
+			}
+			rec.addChannel(strings.TrimSpace(name), strings.TrimSpace(details))
+		}
+		rec.Contact.PreferredLanguage = splitList(get("preferred_language"))
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// formulaPrefixes are the first characters that make a spreadsheet treat a
+// cell as a formula, plus the apostrophe that escapes them.
+const formulaPrefixes = "=+-@\t\r'"
+
+// escapeCell prefixes a cell that would start a formula with an apostrophe,
+// so a contact named "=HYPERLINK(...)" stays text when the export is opened
+// in a spreadsheet. Cells already starting with an apostrophe get another,
+// so unescapeCell restores every cell exactly.
+func escapeCell(cell string) string {
+	if cell != "" && strings.IndexByte(formulaPrefixes, cell[0]) >= 0 {
+		return "'" + cell
+	}
+	return cell
+}
+
+// unescapeCell undoes escapeCell.
+func unescapeCell(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.IndexByte(formulaPrefixes, cell[1]) >= 0 {
+		return cell[1:]
+	}
+	return cell
+}
+
+// splitList splits a semicolon separated CSV cell, dropping empty items.
+func splitList(cell string) []string {
+	var items []string
+	for _, item := range strings.Split(cell, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// decodeVCard understands the FN, TEL, EMAIL, IMPP, LANG and X-AGE
+// properties of each card and ignores the rest. The first TEL without an
+// X-CHANNEL parameter is the mobile number; EMAIL, WhatsApp IMPP and TEL
+// properties with X-CHANNEL become preferred channels, in card order.
+func decodeVCard(r io.Reader) ([]Record, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	var current *Record
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name, params := vcardParams(name)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VCARD"):
+			records = append(records, Record{Row: len(records) + 1})
+			current = &records[len(records)-1]
+		case current == nil:
+		case name == "END":
+			current = nil
+		case name == "FN":
+			current.Contact.ContactName = unescapeVCard(value)
+		case name == "TEL" && params["X-CHANNEL"] != "":
+			current.addChannel(params["X-CHANNEL"], value)
+		case name == "TEL" && current.Contact.Mobile == "":
+			current.Contact.Mobile = value
+		case name == "EMAIL":
+			current.addChannel(model.ChannelEmail, value)
+		case name == "IMPP" && strings.HasPrefix(strings.ToLower(value), whatsAppScheme):
+			current.addChannel(model.ChannelWhatsApp, value[len(whatsAppScheme):])
+		case name == "LANG":
+			current.Contact.PreferredLanguage = append(current.Contact.PreferredLanguage, value)
+		case name == "X-AGE":
+			age, err := strconv.Atoi(value)
+			if err != nil {
+				current.Err = fmt.Errorf("X-AGE %q is not a number", value)
+			}
+			current.Contact.Age = age
+		}
+	}
+	return records, nil
+}
+
+// whatsAppScheme prefixes the IMPP value of a WhatsApp channel.
+const whatsAppScheme = "whatsapp:"
+
+// addChannel appends a preferred channel to the record's contact.
+func (rec *Record) addChannel(name, details string) {
+	rec.Contact.PreferredChannel = append(rec.Contact.PreferredChannel, model.Channel{
+		ID:             len(rec.Contact.PreferredChannel),
+		ChannelName:    name,
+		ChannelDetails: details,
+	})
+}
+
+// vcardParams splits a property such as TEL;TYPE=cell into its upper-cased
+// name and parameters. Parameter values keep their case.
+func vcardParams(property string) (string, map[string]string) {
+	parts := strings.Split(property, ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, part := range parts[1:] {
+		key, value, _ := strings.Cut(part, "=")
+		params[strings.ToUpper(key)] = value
+	}
+	return strings.ToUpper(parts[0]), params
+}
+
+// unfold joins vCard continuation lines, which start with a space or tab.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+var (
+	vcardEscaper   = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\n", `\n`)
+	vcardUnescaper = strings.NewReplacer(`\\`, `\`, `\,`, ",", `\;`, ";", `\n`, "\n", `\N`, "\n")
+)
+
+func unescapeVCard(s string) string {
+	return vcardUnescaper.Replace(s)
+}
+
+// Encoder streams contacts in one format. Call Close once after the last
+// contact to finish the document.
+type Encoder interface {
+	Encode(contact model.Contact) error
+	Close() error
+}
+
+// NewEncoder returns an Encoder writing format to w.
+func NewEncoder(format string, w io.Writer) (Encoder, error) {
+	switch format {
+	case FormatJSON:
+		return &jsonEncoder{w: w}, nil
+	case FormatCSV:
+		return &csvEncoder{w: csv.NewWriter(w)}, nil
+	case FormatVCard:
+		return &vcardEncoder{w: w}, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+// jsonEncoder writes a JSON array one element at a time.
+type jsonEncoder struct {
+	w       io.Writer
+	started bool
+}
+
+func (e *jsonEncoder) Encode(contact model.Contact) error {
+	sep := ","
+	if !e.started {
+		sep = "["
+		e.started = true
+	}
+	item, err := json.Marshal(contact)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(e.w, "%s\n%s", sep, item)
+	return err
+}
+
+func (e *jsonEncoder) Close() error {
+	if !e.started {
+		_, err := io.WriteString(e.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(e.w, "\n]\n")
+	return err
+}
+
+type csvEncoder struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (e *csvEncoder) writeHeader() error {
+	if e.headerWritten {
+		return nil
+	}
+	e.headerWritten = true
+	return e.w.Write(CSVHeader)
+}
+
+func (e *csvEncoder) Encode(contact model.Contact) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	age := ""
+	if contact.Age != 0 {
+		age = strconv.Itoa(contact.Age)
+	}
+	channels := make([]string, 0, len(contact.PreferredChannel))
+	for _, ch := range contact.PreferredChannel {
+		channels = append(channels, ch.ChannelName+":"+ch.ChannelDetails)
+	}
+	return e.w.Write([]string{
+		strconv.Itoa(contact.ID),
+		escapeCell(contact.ContactName),
+		age,
+		escapeCell(contact.Mobile),
+		escapeCell(strings.Join(channels, ";")),
+		escapeCell(strings.Join(contact.PreferredLanguage, ";")),
+	})
+}
+
+func (e *csvEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type vcardEncoder struct {
+	w io.Writer
+}
+
+func (e *vcardEncoder) Encode(contact model.Contact) error {
+	var b strings.Builder
+	b.WriteString("BEGIN:VCARD\r\nVERSION:3.0\r\n")
+	fmt.Fprintf(&b, "UID:%d\r\n", contact.ID)
+	fmt.Fprintf(&b, "FN:%s\r\n", vcardEscaper.Replace(contact.ContactName))
+	fmt.Fprintf(&b, "N:%s;;;;\r\n", vcardEscaper.Replace(contact.ContactName))
+	if contact.Mobile != "" {
+		fmt.Fprintf(&b, "TEL;TYPE=cell:%s\r\n", contact.Mobile)
+	}
+	for _, ch := range contact.PreferredChannel {
+		switch ch.ChannelName {
+		case model.ChannelEmail:
+			fmt.Fprintf(&b, "EMAIL:%s\r\n", ch.ChannelDetails)
+		case model.ChannelWhatsApp:
+			fmt.Fprintf(&b, "IMPP;X-SERVICE-TYPE=WhatsApp:%s%s\r\n", whatsAppScheme, ch.ChannelDetails)
+		case model.ChannelPhone:
+			fmt.Fprintf(&b, "TEL;TYPE=voice;X-CHANNEL=%s:%s\r\n", model.ChannelPhone, ch.ChannelDetails)
+		case model.ChannelSMS:
+			fmt.Fprintf(&b, "TEL;TYPE=msg;X-CHANNEL=%s:%s\r\n", model.ChannelSMS, ch.ChannelDetails)
+		}
+	}
+	for i, lang := range contact.PreferredLanguage {
+		fmt.Fprintf(&b, "LANG;PREF=%d:%s\r\n", i+1, lang)
+	}
+	if contact.Age != 0 {
+		fmt.Fprintf(&b, "X-AGE:%d\r\n", contact.Age)
+	}
+	b.WriteString("END:VCARD\r\n")
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+func (e *vcardEncoder) Close() error {
+	return nil
+}
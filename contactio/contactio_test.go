@@ -0,0 +1,126 @@
+package contactio
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AniketGodambe/mongoapi/model"
+)
+
+func TestRoundTrip(t *testing.T) {
+	contact := model.Contact{
+		ID:          7,
+		ContactName: "Asha Rao",
+		Age:         34,
+		Mobile:      "9876543210",
+		PreferredChannel: []model.Channel{
+			{ID: 0, ChannelName: model.ChannelPhone, ChannelDetails: "02212345678"},
+			{ID: 1, ChannelName: model.ChannelEmail, ChannelDetails: "asha@example.com"},
+			{ID: 2, ChannelName: model.ChannelWhatsApp, ChannelDetails: "9876543210"},
+			{ID: 3, ChannelName: model.ChannelSMS, ChannelDetails: "9876543211"},
+		},
+		PreferredLanguage: []string{"hi", "en"},
+	}
+
+	for _, format := range []string{FormatJSON, FormatCSV, FormatVCard} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc, err := NewEncoder(format, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := enc.Encode(contact); err != nil {
+				t.Fatal(err)
+			}
+			if err := enc.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			records, err := Decode(format, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 1 || records[0].Err != nil {
+				t.Fatalf("got records %+v", records)
+			}
+			got := records[0].Contact
+			got.ID = contact.ID
+			if !reflect.DeepEqual(got, contact) {
+				t.Errorf("round trip changed the contact\ngot  %+v\nwant %+v", got, contact)
+			}
+		})
+	}
+}
+
+func TestDecodeVCardFirstTelIsMobile(t *testing.T) {
+	card := strings.Join([]string{
+		"BEGIN:VCARD",
+		"VERSION:3.0",
+		"FN:Ravi",
+		"TEL;TYPE=voice;X-CHANNEL=Phone:02212345678",
+		"TEL;TYPE=cell:9876543210",
+		"TEL:9999999999",
+		"END:VCARD",
+	}, "\r\n")
+
+	records, err := Decode(FormatVCard, strings.NewReader(card))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := records[0].Contact
+	if got.Mobile != "9876543210" {
+		t.Errorf("mobile = %q, want the first TEL without X-CHANNEL", got.Mobile)
+	}
+	want := []model.Channel{{ID: 0, ChannelName: model.ChannelPhone, ChannelDetails: "02212345678"}}
+	if !reflect.DeepEqual(got.PreferredChannel, want) {
+		t.Errorf("channels = %+v, want %+v", got.PreferredChannel, want)
+	}
+}
+
+func TestCSVEscapesFormulas(t *testing.T) {
+	tests := []struct{ name, cell string }{
+		{"=HYPERLINK(\"http://evil.example\",\"click\")", "'=HYPERLINK(\"http://evil.example\",\"click\")"},
+		{"+cmd|' /C calc'!A0", "'+cmd|' /C calc'!A0"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1:A9)", "'@SUM(A1:A9)"},
+		{"'=already quoted", "''=already quoted"},
+		{"'", "''"},
+		{"O'Brien", "O'Brien"},
+		{"Asha = Rao", "Asha = Rao"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			contact := model.Contact{ID: 1, ContactName: tc.name, Mobile: "9876543210"}
+			var buf bytes.Buffer
+			enc, err := NewEncoder(FormatCSV, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := enc.Encode(contact); err != nil {
+				t.Fatal(err)
+			}
+			if err := enc.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			rows, err := csv.NewReader(bytes.NewReader(buf.Bytes())).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rows[1][1]; got != tc.cell {
+				t.Errorf("contact_name cell = %q, want %q", got, tc.cell)
+			}
+
+			records, err := Decode(FormatCSV, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := records[0].Contact.ContactName; got != tc.name {
+				t.Errorf("imported name = %q, want %q", got, tc.name)
+			}
+		})
+	}
+}
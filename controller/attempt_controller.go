@@ -0,0 +1,234 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/auth"
+	"github.com/AniketGodambe/mongoapi/logging"
+	"github.com/AniketGodambe/mongoapi/model"
+	"github.com/AniketGodambe/mongoapi/quiz"
+	"github.com/AniketGodambe/mongoapi/store"
+	"github.com/gorilla/mux"
+)
+
+// Number of questions drawn into an attempt
+const (
+	defaultAttemptSize = 10
+	maxAttemptSize     = 50
+)
+
+// withPublicQuestions fills Questions from the snapshot so the attempt can be
+// sent to the learner without its answer key.
+func withPublicQuestions(attempt model.Attempt) model.Attempt {
+	attempt.Questions = make([]model.QuizQuestion, 0, len(attempt.Snapshot))
+	for _, q := range attempt.Snapshot {
+		attempt.Questions = append(attempt.Questions, quiz.Public(q))
+	}
+	return attempt
+}
+
+// ownsAttempt reports whether the caller may see attempt: its own user, or an
+// admin reviewing someone else's.
+func ownsAttempt(ctx context.Context, attempt *model.Attempt) bool {
+	id, ok := auth.FromContext(ctx)
+	return ok && (attempt.UserID == id.Subject || id.Role.Allows(auth.RoleAdmin))
+}
+
+func (c *Controller) startAttempt(ctx context.Context, userID string, size int) (*model.Attempt, int, string) {
+	questions, err := c.questions.Sample(ctx, size)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error sampling questions", "err", err)
+		return nil, http.StatusInternalServerError, "Failed to select questions"
+	}
+	if len(questions) == 0 {
+		return nil, http.StatusConflict, "No questions are available"
+	}
+
+	id, err := c.ids.Next(ctx, store.AttemptsSequence)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error generating attempt ID", "err", err)
+		return nil, http.StatusInternalServerError, "Failed to generate attempt ID"
+	}
+
+	attempt := model.Attempt{
+		ID:        id,
+		UserID:    userID,
+		Status:    model.AttemptInProgress,
+		StartedAt: time.Now(),
+	}
+	for _, q := range questions {
+		attempt.Snapshot = append(attempt.Snapshot, quiz.Shuffle(q))
+	}
+
+	if err := c.attempts.Insert(ctx, attempt); err != nil {
+		logging.FromContext(ctx).Error("Error inserting attempt", "err", err)
+		return nil, http.StatusInternalServerError, "Failed to start attempt"
+	}
+	return &attempt, http.StatusCreated, ""
+}
+
+// StartAttemptHandler starts an attempt on a random selection of visible questions
+func (c *Controller) StartAttemptHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodPost)
+
+	var request struct {
+		Count int `json:"count"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if request.Count == 0 {
+		request.Count = defaultAttemptSize
+	}
+	if request.Count < 1 || request.Count > maxAttemptSize {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("count must be between 1 and %d", maxAttemptSize))
+		return
+	}
+
+	// The attempt belongs to the caller; Require guarantees there is one
+	caller, _ := auth.FromContext(r.Context())
+	attempt, statusCode, message := c.startAttempt(r.Context(), caller.Subject, request.Count)
+	if attempt == nil {
+		respondWithError(w, statusCode, message)
+		return
+	}
+	respondWithJSON(w, statusCode, withPublicQuestions(*attempt))
+}
+
+// submitAttempt grades the answers against the attempt's snapshot. Questions
+// left unanswered count as wrong. Only the user who started the attempt may
+// submit it.
+func (c *Controller) submitAttempt(ctx context.Context, id int, answers []model.QuizAnswer) (*model.Attempt, int, string) {
+	attempt, err := c.attempts.FindByID(ctx, id)
+	if err == store.ErrNotFound {
+		return nil, http.StatusNotFound, "Attempt not found"
+	} else if err != nil {
+		logging.FromContext(ctx).Error("Error loading attempt", "err", err)
+		return nil, http.StatusInternalServerError, "Failed to load attempt"
+	}
+	if caller, _ := auth.FromContext(ctx); attempt.UserID != caller.Subject {
+		return nil, http.StatusNotFound, "Attempt not found"
+	}
+	if attempt.Status != model.AttemptInProgress {
+		return nil, http.StatusConflict, "Attempt was already submitted"
+	}
+
+	given := make(map[int]model.QuizAnswer, len(answers))
+	inAttempt := make(map[int]bool, len(attempt.Snapshot))
+	for _, q := range attempt.Snapshot {
+		inAttempt[q.ID] = true
+	}
+	for _, answer := range answers {
+		if !inAttempt[answer.QuestionID] {
+			return nil, http.StatusBadRequest, fmt.Sprintf("Question %d is not part of this attempt", answer.QuestionID)
+		}
+		if _, dup := given[answer.QuestionID]; dup {
+			return nil, http.StatusBadRequest, fmt.Sprintf("Question %d answered more than once", answer.QuestionID)
+		}
+		given[answer.QuestionID] = answer
+	}
+
+	result := &model.QuizResult{Total: len(attempt.Snapshot)}
+	for _, q := range attempt.Snapshot {
+		graded := quiz.Result(q, given[q.ID])
+		if graded.Correct {
+			result.Score++
+		}
+		result.Results = append(result.Results, graded)
+	}
+
+	now := time.Now()
+	attempt.Status = model.AttemptSubmitted
+	attempt.SubmittedAt = &now
+	attempt.DurationSeconds = now.Sub(attempt.StartedAt).Seconds()
+	attempt.Result = result
+
+	err = c.attempts.Submit(ctx, *attempt)
+	if err == store.ErrNotFound {
+		return nil, http.StatusConflict, "Attempt was already submitted"
+	} else if err != nil {
+		logging.FromContext(ctx).Error("Error submitting attempt", "err", err)
+		return nil, http.StatusInternalServerError, "Failed to submit attempt"
+	}
+	return attempt, http.StatusOK, ""
+}
+
+// SubmitAttemptHandler records and grades the answers of an attempt
+func (c *Controller) SubmitAttemptHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodPost)
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid attempt ID")
+		return
+	}
+
+	var submission model.QuizSubmission
+	if err := json.NewDecoder(r.Body).Decode(&submission); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	attempt, statusCode, message := c.submitAttempt(r.Context(), id, submission.Answers)
+	if attempt == nil {
+		respondWithError(w, statusCode, message)
+		return
+	}
+	respondWithJSON(w, statusCode, withPublicQuestions(*attempt))
+}
+
+// GetAttemptsHandler lists the caller's past attempts, newest first. Admins
+// may name another user with user_id.
+func (c *Controller) GetAttemptsHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodGet)
+
+	caller, _ := auth.FromContext(r.Context())
+	userID := caller.Subject
+	if other := r.URL.Query().Get("user_id"); other != "" && other != userID {
+		if !caller.Role.Allows(auth.RoleAdmin) {
+			respondWithError(w, http.StatusForbidden, "Only admins can list another user's attempts")
+			return
+		}
+		userID = other
+	}
+
+	attempts, err := c.attempts.ListByUser(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error listing attempts", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve attempts")
+		return
+	}
+	if attempts == nil {
+		attempts = []model.Attempt{}
+	}
+	respondWithJSON(w, http.StatusOK, attempts)
+}
+
+// GetAttemptHandler returns one of the caller's attempts with its questions
+// for review. Other users' attempts are reported as not found.
+func (c *Controller) GetAttemptHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodGet)
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid attempt ID")
+		return
+	}
+
+	attempt, err := c.attempts.FindByID(r.Context(), id)
+	if err == store.ErrNotFound || (err == nil && !ownsAttempt(r.Context(), attempt)) {
+		respondWithError(w, http.StatusNotFound, "Attempt not found")
+		return
+	} else if err != nil {
+		logging.FromContext(r.Context()).Error("Error loading attempt", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve attempt")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, withPublicQuestions(*attempt))
+}
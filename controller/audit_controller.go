@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/logging"
+	"github.com/AniketGodambe/mongoapi/model"
+	"github.com/AniketGodambe/mongoapi/store"
+	"github.com/gorilla/mux"
+)
+
+// Audit log page sizes
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// origin identifies who made a change and through which route.
+type origin struct {
+	actor string
+	route string
+}
+
+// originOf describes the caller of r and the route template it matched.
+func originOf(r *http.Request) origin {
+	route := r.URL.Path
+	if current := mux.CurrentRoute(r); current != nil {
+		if tpl, err := current.GetPathTemplate(); err == nil {
+			route = tpl
+		}
+	}
+	return origin{actor: actorOf(r), route: r.Method + " " + route}
+}
+
+// snapshot copies v in its JSON form. A nil pointer gives a nil snapshot.
+func snapshot(ctx context.Context, v interface{}) model.Snapshot {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error taking audit snapshot", "err", err)
+		return nil
+	}
+	var s model.Snapshot
+	if err := json.Unmarshal(raw, &s); err != nil {
+		logging.FromContext(ctx).Error("Error taking audit snapshot", "err", err)
+		return nil
+	}
+	return s
+}
+
+// audit logs a change that has already been made, so failures are logged
+// rather than reported to the caller, and the entry is written even if the
+// caller has hung up.
+func (c *Controller) audit(ctx context.Context, o origin, action, targetType string, targetID int, before, after interface{}) {
+	err := c.audits.Append(context.WithoutCancel(ctx), model.AuditEntry{
+		Actor:      o.actor,
+		Action:     action,
+		Route:      o.route,
+		TargetType: targetType,
+		TargetID:   targetID,
+		At:         time.Now(),
+		Before:     snapshot(ctx, before),
+		After:      snapshot(ctx, after),
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error writing audit log", "err", err)
+	}
+}
+
+// parseAuditQuery reads the audit log filters from the query string
+func parseAuditQuery(values url.Values) (store.AuditQuery, string) {
+	query := store.AuditQuery{
+		Actor:      values.Get("actor"),
+		Action:     values.Get("action"),
+		TargetType: values.Get("target_type"),
+		Limit:      defaultAuditLimit,
+	}
+
+	if v := values.Get("target_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id < 1 {
+			return query, "target_id must be a positive integer"
+		}
+		query.TargetID = id
+	}
+	for _, bound := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &query.Since}, {"until", &query.Until}} {
+		if v := values.Get(bound.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return query, bound.name + " must be an RFC 3339 time such as 2026-01-02T15:04:05Z"
+			}
+			*bound.dst = t
+		}
+	}
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxAuditLimit {
+			return query, "limit must be between 1 and " + strconv.Itoa(maxAuditLimit)
+		}
+		query.Limit = limit
+	}
+	return query, ""
+}
+
+// GetAuditLogHandler lists audit entries, newest first, filtered by actor,
+// action, target_type, target_id and a since/until time range
+func (c *Controller) GetAuditLogHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodGet)
+
+	query, message := parseAuditQuery(r.URL.Query())
+	if message != "" {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	entries, err := c.audits.Find(r.Context(), query)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error reading audit log", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve audit log")
+		return
+	}
+	if entries == nil {
+		entries = []model.AuditEntry{}
+	}
+	respondWithJSON(w, http.StatusOK, entries)
+}
@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/AniketGodambe/mongoapi/contactio"
+	"github.com/AniketGodambe/mongoapi/logging"
+	"github.com/AniketGodambe/mongoapi/model"
+	"github.com/AniketGodambe/mongoapi/store"
+)
+
+// maxImportBytes caps the size of an uploaded import file
+const maxImportBytes = 10 << 20
+
+// exportFlushEvery is how many contacts are written between flushes
+const exportFlushEvery = 100
+
+// importContacts validates every record like CreateContactHandler does and,
+// unless dryRun is set, inserts the valid ones
+func (c *Controller) importContacts(ctx context.Context, o origin, format string, records []contactio.Record, dryRun bool) model.ImportReport {
+	report := model.ImportReport{
+		Format: format,
+		DryRun: dryRun,
+		Total:  len(records),
+		Errors: []model.ImportRowError{},
+	}
+	fail := func(rec contactio.Record, message string) {
+		report.Failed++
+		report.Errors = append(report.Errors, model.ImportRowError{
+			Row:     rec.Row,
+			Mobile:  rec.Contact.Mobile,
+			Message: message,
+		})
+	}
+
+	firstRow := map[string]int{}
+	for _, rec := range records {
+		if rec.Err != nil {
+			fail(rec, "Invalid row: "+rec.Err.Error())
+			continue
+		}
+		if message := validateContact(&rec.Contact); message != "" {
+			fail(rec, message)
+			continue
+		}
+		if row, dup := firstRow[rec.Contact.Mobile]; dup {
+			fail(rec, fmt.Sprintf("Mobile number already used in row %d", row))
+			continue
+		}
+		firstRow[rec.Contact.Mobile] = rec.Row
+
+		// IDs always come from the sequence, never from the file
+		rec.Contact.ID = 0
+
+		if dryRun {
+			existing, err := c.contacts.FindByMobile(ctx, rec.Contact.Mobile)
+			if err == nil {
+				fail(rec, mobileTaken(existing))
+				continue
+			} else if err != store.ErrNotFound {
+				logging.FromContext(ctx).Error("Error checking existing contact", "err", err)
+				fail(rec, "Database error!")
+				continue
+			}
+			report.Imported++
+			continue
+		}
+
+		statusCode, message, id := c.createOneContact(ctx, o, rec.Contact)
+		if statusCode != http.StatusCreated {
+			fail(rec, message)
+			continue
+		}
+		report.Imported++
+		report.IDs = append(report.IDs, id)
+	}
+
+	return report
+}
+
+// ImportContactsHandler bulk-loads contacts from a JSON, CSV or vCard body.
+// The format comes from ?format= or the Content-Type; ?dry_run=true only
+// validates.
+func (c *Controller) ImportContactsHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodPost)
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format, _ = contactio.FormatFromContentType(r.Header.Get("Content-Type"))
+	}
+	if _, ok := contactio.ContentTypes[format]; !ok {
+		respondWithError(w, http.StatusBadRequest, "format must be json, csv or vcf")
+		return
+	}
+
+	dryRun := false
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			respondWithError(w, http.StatusBadRequest, "dry_run must be true or false")
+			return
+		}
+	}
+
+	records, err := contactio.Decode(format, http.MaxBytesReader(w, r.Body, maxImportBytes))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid import file: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, c.importContacts(r.Context(), originOf(r), format, records, dryRun))
+}
+
+// ExportContactsHandler streams every contact as JSON, CSV or vCard
+func (c *Controller) ExportContactsHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = contactio.FormatJSON
+	}
+	contentType, ok := contactio.ContentTypes[format]
+	if !ok {
+		setHeaders(w, http.MethodGet)
+		respondWithError(w, http.StatusBadRequest, "format must be json, csv or vcf")
+		return
+	}
+
+	encoder, _ := contactio.NewEncoder(format, w)
+	// The access log and metrics wrap w, so flush through its Unwrap chain
+	flusher := http.NewResponseController(w)
+	started := false
+	start := func() {
+		if started {
+			return
+		}
+		started = true
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Access-Control-Allow-Methods", http.MethodGet)
+		w.Header().Set("Content-Disposition", `attachment; filename="contacts.`+format+`"`)
+		w.WriteHeader(http.StatusOK)
+	}
+
+	count := 0
+	err := c.contacts.Each(r.Context(), func(contact model.Contact) error {
+		start()
+		if err := encoder.Encode(contact); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushEvery == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error exporting contacts", "err", err)
+		// Once the headers are gone all we can do is cut the stream short
+		if !started {
+			setHeaders(w, http.MethodGet)
+			respondWithError(w, http.StatusInternalServerError, "Failed to export contacts")
+		}
+		return
+	}
+
+	start()
+	if err := encoder.Close(); err != nil {
+		logging.FromContext(r.Context()).Error("Error finishing contact export", "err", err)
+	}
+}
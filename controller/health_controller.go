@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/model"
+	"github.com/AniketGodambe/mongoapi/store"
+)
+
+// Readiness deadlines. A database slower than readySlow to answer is
+// reported degraded; one that misses readyTimeout is unavailable.
+const (
+	readyTimeout = 2 * time.Second
+	readySlow    = 500 * time.Millisecond
+)
+
+// respondWithHealth writes a health report, answering 503 unless the status
+// is ok or degraded.
+func respondWithHealth(w http.ResponseWriter, report model.HealthReport) {
+	statusCode, message := http.StatusOK, "Ready"
+	if report.Status != model.HealthOK && report.Status != model.HealthDegraded {
+		statusCode, message = http.StatusServiceUnavailable, "Not ready"
+	}
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(model.Response{
+		Message:    message,
+		StatusCode: statusCode,
+		Data:       report,
+	})
+}
+
+// checkDatabase times one health check of the stores' database.
+func (c *Controller) checkDatabase(ctx context.Context) model.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.health.Check(ctx)
+	latency := time.Since(start)
+
+	check := model.HealthCheck{
+		Name:      "database",
+		Status:    model.HealthOK,
+		LatencyMS: float64(latency.Microseconds()) / 1000,
+	}
+	switch {
+	case errors.Is(err, store.ErrNotInitialized):
+		check.Status = model.HealthStarting
+		check.Error = err.Error()
+	case err != nil:
+		check.Status = model.HealthUnavailable
+		check.Error = err.Error()
+	case latency > readySlow:
+		check.Status = model.HealthDegraded
+	}
+	return check
+}
+
+// HealthzHandler reports that the process is up. It never looks at the
+// database, so an outage does not get the server restarted.
+func (c *Controller) HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodGet)
+
+	respondWithHealth(w, model.HealthReport{
+		Status:        model.HealthOK,
+		UptimeSeconds: time.Since(c.started).Seconds(),
+	})
+}
+
+// ReadyzHandler reports whether the server can take traffic: the database
+// must be initialized and answer within readyTimeout. A slow database is
+// reported degraded but still ready.
+func (c *Controller) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodGet)
+
+	report := model.HealthReport{
+		Status:        model.HealthOK,
+		UptimeSeconds: time.Since(c.started).Seconds(),
+	}
+	if c.health != nil {
+		check := c.checkDatabase(r.Context())
+		report.Status = check.Status
+		report.Checks = append(report.Checks, check)
+	}
+	respondWithHealth(w, report)
+}
@@ -2,72 +2,248 @@ package controller
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
 	"strconv"
+	"strings"
 
-	"log"
 	"net/http"
 	"time"
 
+	"github.com/AniketGodambe/mongoapi/logging"
 	"github.com/AniketGodambe/mongoapi/model"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"github.com/AniketGodambe/mongoapi/quiz"
+	"github.com/AniketGodambe/mongoapi/store"
 )
 
-func getAllQuestions() ([]model.Question, error) {
-	var questions []model.Question
-	cursor, err := QuestionsCollection.Find(context.Background(), bson.D{})
+// Page size bounds for the questions list
+const (
+	defaultQuestionsLimit = 50
+	maxQuestionsLimit     = 200
+)
+
+// listCursor is the opaque next_cursor handed to clients. It remembers the
+// sort it was issued for so it cannot be replayed against another ordering.
+type listCursor struct {
+	Sort string `json:"s"`
+	store.QuestionCursor
+}
+
+func encodeListCursor(sort string, cursor *store.QuestionCursor) string {
+	raw, _ := json.Marshal(listCursor{Sort: sort, QuestionCursor: *cursor})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeListCursor(sort, encoded string) (*store.QuestionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(context.Background())
-
-	if err := cursor.All(context.Background(), &questions); err != nil {
+	var cursor listCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
 		return nil, err
 	}
-	return questions, nil
+	if cursor.Sort != sort {
+		return nil, errors.New("cursor was issued for a different sort")
+	}
+	return &cursor.QuestionCursor, nil
+}
+
+// sortKey renders the query's ordering the way the sort parameter spells it.
+func sortKey(query store.QuestionQuery) string {
+	if query.Desc {
+		return "-" + query.SortBy
+	}
+	return query.SortBy
 }
 
-// GetAllQuestionsHandler handles API request to fetch all questions
-func GetAllQuestionsHandler(w http.ResponseWriter, r *http.Request) {
+// parseQuestionQuery reads limit, page, cursor, sort, hidden, q, category,
+// tag and difficulty from the URL. tag may repeat or hold a comma-separated
+// list; questions must carry every tag.
+func parseQuestionQuery(values url.Values) (store.QuestionQuery, int, error) {
+	query := store.QuestionQuery{
+		Limit:    defaultQuestionsLimit,
+		Search:   values.Get("q"),
+		Category: strings.TrimSpace(values.Get("category")),
+	}
+
+	for _, v := range values["tag"] {
+		for _, tag := range strings.Split(v, ",") {
+			if tag = quiz.NormalizeTag(tag); tag != "" {
+				query.Tags = append(query.Tags, tag)
+			}
+		}
+	}
+
+	if v := values.Get("difficulty"); v != "" {
+		query.Difficulty = strings.ToLower(v)
+		if !slices.Contains(quiz.Difficulties, query.Difficulty) {
+			return query, 0, fmt.Errorf("difficulty must be one of %s", strings.Join(quiz.Difficulties, ", "))
+		}
+	}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxQuestionsLimit {
+			return query, 0, fmt.Errorf("limit must be between 1 and %d", maxQuestionsLimit)
+		}
+		query.Limit = limit
+	}
+
+	sort := values.Get("sort")
+	if sort == "" {
+		sort = store.SortByID
+	}
+	query.SortBy = strings.TrimPrefix(sort, "-")
+	query.Desc = strings.HasPrefix(sort, "-")
+	switch query.SortBy {
+	case store.SortByID, store.SortByCreatedAt, store.SortByLastModified:
+	default:
+		return query, 0, errors.New("sort must be one of id, created_at, last_modified (prefix with - for descending)")
+	}
+
+	if v := values.Get("hidden"); v != "" {
+		hidden, err := strconv.ParseBool(v)
+		if err != nil {
+			return query, 0, errors.New("hidden must be true or false")
+		}
+		query.Hidden = &hidden
+	}
+
+	page := 0
+	cursor := values.Get("cursor")
+	if v := values.Get("page"); v != "" {
+		if cursor != "" {
+			return query, 0, errors.New("use either page or cursor, not both")
+		}
+		p, err := strconv.Atoi(v)
+		if err != nil || p < 1 {
+			return query, 0, errors.New("page must be a positive integer")
+		}
+		page = p
+		query.Skip = (page - 1) * query.Limit
+	}
+	if cursor != "" {
+		after, err := decodeListCursor(sortKey(query), cursor)
+		if err != nil {
+			return query, 0, errors.New("invalid cursor")
+		}
+		query.After = after
+	}
+
+	return query, page, nil
+}
+
+// getAllQuestions runs the query and wraps the result in the list envelope
+func (c *Controller) getAllQuestions(ctx context.Context, query store.QuestionQuery, page int) (model.Page[model.Question], error) {
+	result, err := c.questions.Find(ctx, query)
+	if err != nil {
+		return model.Page[model.Question]{}, err
+	}
+
+	list := model.Page[model.Question]{
+		Items: result.Questions,
+		Total: result.Total,
+		Limit: query.Limit,
+		Page:  page,
+	}
+	if list.Items == nil {
+		list.Items = []model.Question{}
+	}
+	if result.HasMore {
+		last := result.Questions[len(result.Questions)-1]
+		list.NextCursor = encodeListCursor(sortKey(query), query.CursorFor(last))
+	}
+	return list, nil
+}
+
+// GetAllQuestionsHandler handles API request to fetch a page of questions
+func (c *Controller) GetAllQuestionsHandler(w http.ResponseWriter, r *http.Request) {
 	setHeaders(w, http.MethodGet)
-	questions, err := getAllQuestions()
+
+	query, page, err := parseQuestionQuery(r.URL.Query())
 	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	list, err := c.getAllQuestions(r.Context(), query, page)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error listing questions", "err", err)
 		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve questions")
 		return
 	}
-	respondWithJSON(w, http.StatusOK, questions)
+	respondWithJSON(w, http.StatusOK, list)
+}
+
+// GetQuestionCategoriesHandler lists the categories in use with their question counts
+func (c *Controller) GetQuestionCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodGet)
+
+	counts, err := c.questions.Categories(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error counting question categories", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve categories")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, counts)
 }
 
-// Create a new question
-func createOneQuestion(question model.Question) (bool, string, int) {
+// GetQuestionTagsHandler lists the tags in use with their question counts
+func (c *Controller) GetQuestionTagsHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodGet)
+
+	counts, err := c.questions.Tags(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error counting question tags", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve tags")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, counts)
+}
+
+// Create a new question, returning the HTTP status, a message and the new ID
+func (c *Controller) createOneQuestion(ctx context.Context, o origin, question model.Question) (int, string, int) {
 	// Check if the question already exists
-	existingQuestion := QuestionsCollection.FindOne(context.TODO(), bson.M{"question": question.Question})
-	if existingQuestion.Err() == nil {
-		return false, "Question already exists!", 0
+	exists, err := c.questions.ExistsByText(ctx, question.Question, 0)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error checking for duplicate questions", "err", err)
+		return http.StatusInternalServerError, "Failed to validate question uniqueness!", 0
+	} else if exists {
+		return http.StatusConflict, "Question already exists!", 0
 	}
 
 	// Generate a new ID
-	count, err := QuestionsCollection.CountDocuments(context.TODO(), bson.M{})
+	question.ID, err = c.ids.Next(ctx, store.QuestionsSequence)
 	if err != nil {
-		return false, "Failed to generate question ID!", 0
+		logging.FromContext(ctx).Error("Error generating question ID", "err", err)
+		return http.StatusInternalServerError, "Failed to generate question ID!", 0
 	}
 
-	question.ID = int(count) + 1
 	question.CreatedAt = time.Now()
 	question.LastModified = time.Now()
+	question.DeletedAt = nil
 
 	// Insert the new question
-	_, err = QuestionsCollection.InsertOne(context.TODO(), question)
-	if err != nil {
-		return false, "Failed to insert question!", 0
+	err = c.questions.Insert(ctx, question)
+	if err == store.ErrDuplicate {
+		return http.StatusConflict, "Question already exists!", 0
+	} else if err != nil {
+		logging.FromContext(ctx).Error("Error inserting question", "err", err)
+		return http.StatusInternalServerError, "Failed to insert question!", 0
 	}
 
-	return true, "Question inserted successfully!", question.ID
+	c.recordRevision(ctx, change{origin: o, action: model.RevisionCreated}, question.ID, nil)
+	c.audit(ctx, o, model.AuditCreated, model.AuditQuestion, question.ID, nil, question)
+	return http.StatusCreated, "Question inserted successfully!", question.ID
 }
 
 // AddQuestionHandler handles API request to add a new question
-func AddQuestionHandler(w http.ResponseWriter, r *http.Request) {
+func (c *Controller) AddQuestionHandler(w http.ResponseWriter, r *http.Request) {
 	setHeaders(w, http.MethodPost)
 
 	var newQuestion model.Question
@@ -75,64 +251,60 @@ func AddQuestionHandler(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	if errs := quiz.Validate(&newQuestion); errs != nil {
+		respondWithFieldErrors(w, errs)
+		return
+	}
 
-	success, _, questionID := createOneQuestion(newQuestion)
-	statusCode := http.StatusCreated
-	if !success {
-		statusCode = http.StatusConflict
+	statusCode, message, questionID := c.createOneQuestion(r.Context(), originOf(r), newQuestion)
+	if statusCode != http.StatusCreated {
+		respondWithError(w, statusCode, message)
+		return
 	}
 
 	respondWithJSON(w, statusCode, map[string]int{"question_id": questionID})
 }
 
-func updateQuestion(updatedQuestion model.Question) (bool, string) {
+// updateQuestion overwrites a question and records the change as a revision
+func (c *Controller) updateQuestion(ctx context.Context, ch change, updatedQuestion model.Question) (int, string) {
 	// Check if the question exists
-	var existingQuestion model.Question
-	err := QuestionsCollection.FindOne(context.TODO(), bson.M{"id": updatedQuestion.ID}).Decode(&existingQuestion)
-	if err != nil {
-		return false, "Question not found!"
+	existing, err := c.questions.FindByID(ctx, updatedQuestion.ID)
+	if err == store.ErrNotFound {
+		return http.StatusNotFound, "Question not found!"
+	} else if err != nil {
+		logging.FromContext(ctx).Error("Error loading question", "err", err)
+		return http.StatusInternalServerError, "Failed to update question!"
 	}
 
 	// Check if the new question text already exists (excluding the current question)
-	filter := bson.M{
-		"question": updatedQuestion.Question,
-		"id":       bson.M{"$ne": updatedQuestion.ID}, // Ensures the same question isn't being duplicated
-	}
-
-	count, err := QuestionsCollection.CountDocuments(context.TODO(), filter)
+	exists, err := c.questions.ExistsByText(ctx, updatedQuestion.Question, updatedQuestion.ID)
 	if err != nil {
-		log.Println("Error checking for duplicate questions:", err)
-		return false, "Failed to validate question uniqueness!"
+		logging.FromContext(ctx).Error("Error checking for duplicate questions", "err", err)
+		return http.StatusInternalServerError, "Failed to validate question uniqueness!"
 	}
 
-	if count > 0 {
-		return false, "A question with this text already exists!"
-	}
-
-	// Update fields
-	update := bson.M{
-		"$set": bson.M{
-			"question":       updatedQuestion.Question,
-			"options":        updatedQuestion.Options,
-			"correct_answer": updatedQuestion.CorrectAns,
-			"reason":         updatedQuestion.Reason,
-			"hidden":         updatedQuestion.Hidden,
-			"last_modified":  time.Now(),
-		},
+	if exists {
+		return http.StatusConflict, "A question with this text already exists!"
 	}
 
 	// Perform update operation
-	_, err = QuestionsCollection.UpdateOne(context.TODO(), bson.M{"id": updatedQuestion.ID}, update)
-	if err != nil {
-		log.Println("Error updating question:", err)
-		return false, "Failed to update question!"
-	}
-
-	return true, "Question updated successfully!"
+	updatedQuestion.LastModified = time.Now()
+	updatedQuestion.DeletedAt = nil
+	_, err = c.questions.Update(ctx, updatedQuestion)
+	if err == store.ErrDuplicate {
+		return http.StatusConflict, "A question with this text already exists!"
+	} else if err != nil {
+		logging.FromContext(ctx).Error("Error updating question", "err", err)
+		return http.StatusInternalServerError, "Failed to update question!"
+	}
+
+	c.recordRevision(ctx, ch, updatedQuestion.ID, existing)
+	c.auditQuestion(ctx, ch, updatedQuestion.ID, existing)
+	return http.StatusOK, "Question updated successfully!"
 }
 
 // Update an existing question
-func UpdateQuestionHandler(w http.ResponseWriter, r *http.Request) {
+func (c *Controller) UpdateQuestionHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Methods", "PUT")
 
@@ -158,23 +330,23 @@ func UpdateQuestionHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate ID
-	if updatedQuestion.ID == 0 {
+	if updatedQuestion.ID <= 0 {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(model.Response{
-			Message:    "Question ID is required",
+			Message:    "A positive question ID is required",
 			StatusCode: http.StatusBadRequest,
 		})
 		return
 	}
 
-	// Call function to update question
-	success, message := updateQuestion(updatedQuestion)
-
-	statusCode := http.StatusOK
-	if !success {
-		statusCode = http.StatusConflict
+	if errs := quiz.Validate(&updatedQuestion); errs != nil {
+		respondWithFieldErrors(w, errs)
+		return
 	}
 
+	// Call function to update question
+	statusCode, message := c.updateQuestion(r.Context(), change{origin: originOf(r), action: model.RevisionUpdated}, updatedQuestion)
+
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(model.Response{
 		Message:    message,
@@ -182,25 +354,31 @@ func UpdateQuestionHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// Delete a question
-func DeleteQuestionHandler(w http.ResponseWriter, r *http.Request) {
-	idParam := r.URL.Query().Get("id")
-	id, err := primitive.ObjectIDFromHex(idParam)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+// Move a question to the trash
+func (c *Controller) DeleteQuestionHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, statusCode, message := c.questionID(ctx, r.URL.Query().Get("id"))
+	if id == 0 {
+		w.WriteHeader(statusCode)
 		json.NewEncoder(w).Encode(model.Response{
-			Message:    "Invalid question ID",
-			StatusCode: http.StatusBadRequest,
+			Message:    message,
+			StatusCode: statusCode,
 		})
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	filter := bson.M{"_id": id}
-
-	result, err := QuestionsCollection.DeleteOne(ctx, filter)
-	if err != nil || result.DeletedCount == 0 {
+	deletedCount, err := c.trashQuestion(ctx, originOf(r), id)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error deleting question", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(model.Response{
+			Message:    "Database error!",
+			StatusCode: http.StatusInternalServerError,
+		})
+		return
+	}
+	if deletedCount == 0 {
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(model.Response{
 			Message:    "Question not found",
@@ -211,47 +389,45 @@ func DeleteQuestionHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(model.Response{
-		Message:    "Question deleted successfully",
+		Message:    "Question moved to trash",
 		StatusCode: http.StatusOK,
 	})
 }
 
 // Toggle hide/show question
-func toggleQuestionVisibility(questionID int) (bool, string, bool) {
+func (c *Controller) toggleQuestionVisibility(ctx context.Context, o origin, questionID int) (int, string, bool) {
 	// Find the existing question
-	var question model.Question
-	err := QuestionsCollection.FindOne(context.TODO(), bson.M{"id": questionID}).Decode(&question)
-	if err != nil {
-		return false, "Question not found!", false
+	question, err := c.questions.FindByID(ctx, questionID)
+	if err == store.ErrNotFound {
+		return http.StatusNotFound, "Question not found!", false
+	} else if err != nil {
+		logging.FromContext(ctx).Error("Error loading question", "err", err)
+		return http.StatusInternalServerError, "Failed to toggle question visibility!", false
 	}
 
 	// Toggle the `hidden` status
 	newHiddenStatus := !question.Hidden
 
 	// Update the question in the database
-	update := bson.M{
-		"$set": bson.M{
-			"hidden":        newHiddenStatus,
-			"last_modified": time.Now(),
-		},
-	}
-
-	_, err = QuestionsCollection.UpdateOne(context.TODO(), bson.M{"id": questionID}, update)
+	_, err = c.questions.SetHidden(ctx, questionID, newHiddenStatus)
 	if err != nil {
-		log.Println("Error updating question visibility:", err)
-		return false, "Failed to toggle question visibility!", question.Hidden
+		logging.FromContext(ctx).Error("Error updating question visibility", "err", err)
+		return http.StatusInternalServerError, "Failed to toggle question visibility!", question.Hidden
 	}
 
 	// Return success and the new status
-	statusMessage := "Question is now hidden"
+	statusMessage, action := "Question is now hidden", model.RevisionHidden
 	if !newHiddenStatus {
-		statusMessage = "Question is now visible"
+		statusMessage, action = "Question is now visible", model.RevisionShown
 	}
+	ch := change{origin: o, action: action}
+	c.recordRevision(ctx, ch, questionID, question)
+	c.auditQuestion(ctx, ch, questionID, question)
 
-	return true, statusMessage, newHiddenStatus
+	return http.StatusOK, statusMessage, newHiddenStatus
 }
 
-func ToggleQuestionVisibilityHandler(w http.ResponseWriter, r *http.Request) {
+func (c *Controller) ToggleQuestionVisibilityHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Methods", "PUT")
 
@@ -280,22 +456,17 @@ func ToggleQuestionVisibilityHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate ID
-	if request.ID == 0 {
+	if request.ID <= 0 {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(model.Response{
-			Message:    "Question ID is required",
+			Message:    "A positive question ID is required",
 			StatusCode: http.StatusBadRequest,
 		})
 		return
 	}
 
 	// Call toggle function
-	success, message, newStatus := toggleQuestionVisibility(request.ID)
-
-	statusCode := http.StatusOK
-	if !success {
-		statusCode = http.StatusNotFound
-	}
+	statusCode, message, newStatus := c.toggleQuestionVisibility(r.Context(), originOf(r), request.ID)
 
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(model.Response{
@@ -305,19 +476,11 @@ func ToggleQuestionVisibilityHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func getQuestionById(id int) (*model.Question, error) {
-	var question model.Question
-	filter := bson.M{"id": id}
-
-	err := QuestionsCollection.FindOne(context.Background(), filter).Decode(&question)
-	if err != nil {
-		return nil, err
-	}
-
-	return &question, nil
+func (c *Controller) getQuestionById(ctx context.Context, id int) (*model.Question, error) {
+	return c.questions.FindByID(ctx, id)
 }
 
-func GetQuestionByIdHandler(w http.ResponseWriter, r *http.Request) {
+func (c *Controller) GetQuestionByIdHandler(w http.ResponseWriter, r *http.Request) {
 	setHeaders(w, http.MethodGet)
 
 	// Parse the query parameter "id" from the URL
@@ -330,18 +493,22 @@ func GetQuestionByIdHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Convert idStr to int
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid id parameter")
+	// Resolve the public ID or ObjectID
+	id, statusCode, message := c.questionID(r.Context(), idStr)
+	if id == 0 {
+		respondWithError(w, statusCode, message)
 		return
 	}
 
 	// Fetch the question from database
-	question, err := getQuestionById(id)
-	if err != nil {
+	question, err := c.getQuestionById(r.Context(), id)
+	if err == store.ErrNotFound {
 		respondWithError(w, http.StatusNotFound, "Question not found")
 		return
+	} else if err != nil {
+		logging.FromContext(r.Context()).Error("Error loading question", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Database error!")
+		return
 	}
 
 	// Send response
@@ -363,6 +530,16 @@ func respondWithError(w http.ResponseWriter, statusCode int, message string) {
 	})
 }
 
+// respondWithFieldErrors rejects a request, listing each invalid field in Data
+func respondWithFieldErrors(w http.ResponseWriter, errs []model.FieldError) {
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(model.Response{
+		Message:    "Validation failed",
+		StatusCode: http.StatusBadRequest,
+		Data:       errs,
+	})
+}
+
 func respondWithJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(model.Response{
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/AniketGodambe/mongoapi/logging"
+	"github.com/AniketGodambe/mongoapi/model"
+	"github.com/AniketGodambe/mongoapi/quiz"
+	"github.com/AniketGodambe/mongoapi/store"
+)
+
+// GetQuizQuestionsHandler serves a page of visible questions without answers
+func (c *Controller) GetQuizQuestionsHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodGet)
+
+	query, page, err := parseQuestionQuery(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	visible := false
+	query.Hidden = &visible
+
+	list, err := c.getAllQuestions(r.Context(), query, page)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error listing quiz questions", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve questions")
+		return
+	}
+
+	public := model.Page[model.QuizQuestion]{
+		Items:      make([]model.QuizQuestion, 0, len(list.Items)),
+		Total:      list.Total,
+		Limit:      list.Limit,
+		Page:       list.Page,
+		NextCursor: list.NextCursor,
+	}
+	for _, q := range list.Items {
+		public.Items = append(public.Items, quiz.Public(q))
+	}
+	respondWithJSON(w, http.StatusOK, public)
+}
+
+// gradeSubmission grades every answer against the stored answer key. Hidden
+// and unknown questions are rejected so they cannot be probed for answers.
+func (c *Controller) gradeSubmission(ctx context.Context, submission model.QuizSubmission) (*model.QuizResult, int, string) {
+	result := &model.QuizResult{Total: len(submission.Answers)}
+	seen := make(map[int]bool, len(submission.Answers))
+
+	for _, answer := range submission.Answers {
+		if seen[answer.QuestionID] {
+			return nil, http.StatusBadRequest, fmt.Sprintf("Question %d answered more than once", answer.QuestionID)
+		}
+		seen[answer.QuestionID] = true
+
+		question, err := c.questions.FindByID(ctx, answer.QuestionID)
+		if err == store.ErrNotFound || (err == nil && question.Hidden) {
+			return nil, http.StatusBadRequest, fmt.Sprintf("Unknown question %d", answer.QuestionID)
+		} else if err != nil {
+			logging.FromContext(ctx).Error("Error loading question for grading", "err", err)
+			return nil, http.StatusInternalServerError, "Failed to grade submission"
+		}
+
+		graded := quiz.Result(*question, answer)
+		if graded.Correct {
+			result.Score++
+		}
+		result.Results = append(result.Results, graded)
+	}
+
+	return result, http.StatusOK, ""
+}
+
+// SubmitQuizHandler grades a quiz submission and reveals the answers
+func (c *Controller) SubmitQuizHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodPost)
+
+	var submission model.QuizSubmission
+	if err := json.NewDecoder(r.Body).Decode(&submission); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(submission.Answers) == 0 {
+		respondWithError(w, http.StatusBadRequest, "At least one answer is required")
+		return
+	}
+
+	result, statusCode, message := c.gradeSubmission(r.Context(), submission)
+	if result == nil {
+		respondWithError(w, statusCode, message)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/AniketGodambe/mongoapi/logging"
+	"github.com/AniketGodambe/mongoapi/store"
+)
+
+// Every contact and question route resolves its identifier through the
+// helpers below, so the public ID returned by create works everywhere.
+// Questions also accept their ObjectID hex as a fallback; a contact's _id
+// is its public ID, so an ObjectID can never name one.
+
+// contactID resolves a contact reference to its public ID. On failure the
+// ID is 0 and the status and message describe the error.
+func contactID(raw string) (int, int, string) {
+	ref, err := store.ParseRef(raw)
+	if err != nil || ref.IsObjectID() {
+		return 0, http.StatusBadRequest, "Invalid contact ID"
+	}
+	return ref.ID, http.StatusOK, ""
+}
+
+// questionID resolves a question reference to its public ID, like contactID,
+// falling back to the ObjectID.
+func (c *Controller) questionID(ctx context.Context, raw string) (int, int, string) {
+	ref, err := store.ParseRef(raw)
+	if err != nil {
+		return 0, http.StatusBadRequest, "Invalid question ID"
+	}
+	if !ref.IsObjectID() {
+		return ref.ID, http.StatusOK, ""
+	}
+
+	question, err := c.questions.FindByObjectID(ctx, ref.OID)
+	if err == store.ErrNotFound {
+		return 0, http.StatusNotFound, "Question not found"
+	} else if err != nil {
+		logging.FromContext(ctx).Error("Error resolving question ID", "err", err)
+		return 0, http.StatusInternalServerError, "Database error!"
+	}
+	return question.ID, http.StatusOK, ""
+}
@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/auth"
+	"github.com/AniketGodambe/mongoapi/logging"
+	"github.com/AniketGodambe/mongoapi/model"
+	"github.com/AniketGodambe/mongoapi/quiz"
+	"github.com/AniketGodambe/mongoapi/store"
+	"github.com/gorilla/mux"
+)
+
+// change describes who changed a question and how, for its revision and
+// audit entry.
+type change struct {
+	origin
+	action       string
+	revertedFrom int
+}
+
+// actorOf names the caller for revision and audit records
+func actorOf(r *http.Request) string {
+	if id, ok := auth.FromContext(r.Context()); ok {
+		return id.Subject
+	}
+	return "anonymous"
+}
+
+// recordRevision snapshots the question as stored now. When before is given
+// and the question has no history yet, before is kept as a baseline first so
+// the wording it replaced is not lost. The change has already been written,
+// so failures are logged rather than reported to the caller, and a caller
+// hanging up does not cancel the write.
+func (c *Controller) recordRevision(ctx context.Context, ch change, questionID int, before *model.Question) {
+	ctx = context.WithoutCancel(ctx)
+
+	if before != nil {
+		_, err := c.revisions.Find(ctx, questionID, 1)
+		if err == store.ErrNotFound {
+			baseline := model.QuestionRevision{
+				QuestionID: questionID,
+				Action:     model.RevisionBaseline,
+				CreatedAt:  before.LastModified,
+				Question:   *before,
+			}
+			if _, err := c.revisions.Append(ctx, baseline); err != nil {
+				logging.FromContext(ctx).Error("Error recording baseline revision", "err", err)
+			}
+		} else if err != nil {
+			logging.FromContext(ctx).Error("Error loading revisions", "err", err)
+		}
+	}
+
+	current, err := c.questions.FindByID(ctx, questionID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error loading question for revision", "err", err)
+		return
+	}
+	_, err = c.revisions.Append(ctx, model.QuestionRevision{
+		QuestionID:   questionID,
+		Action:       ch.action,
+		Author:       ch.actor,
+		RevertedFrom: ch.revertedFrom,
+		CreatedAt:    time.Now(),
+		Question:     *current,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error recording question revision", "err", err)
+	}
+}
+
+// auditQuestion logs a change to a question, loading its current state as
+// the after snapshot.
+func (c *Controller) auditQuestion(ctx context.Context, ch change, questionID int, before *model.Question) {
+	ctx = context.WithoutCancel(ctx)
+	after, err := c.questions.FindByID(ctx, questionID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error loading question for audit", "err", err)
+	}
+	c.audit(ctx, ch.origin, ch.action, model.AuditQuestion, questionID, before, after)
+}
+
+// revisionNumber parses a revision number from the URL
+func revisionNumber(raw string) (int, bool) {
+	n, err := strconv.Atoi(raw)
+	return n, err == nil && n > 0
+}
+
+// GetQuestionRevisionsHandler lists every revision of a question, oldest first
+func (c *Controller) GetQuestionRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodGet)
+
+	id, statusCode, message := c.questionID(r.Context(), mux.Vars(r)["id"])
+	if id == 0 {
+		respondWithError(w, statusCode, message)
+		return
+	}
+
+	revisions, err := c.revisions.List(r.Context(), id)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error listing revisions", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve revisions")
+		return
+	}
+	if len(revisions) == 0 {
+		// Questions never changed since tracking began have no history yet
+		if _, err := c.questions.FindByID(r.Context(), id); err == store.ErrNotFound {
+			respondWithError(w, http.StatusNotFound, "Question not found")
+			return
+		}
+		revisions = []model.QuestionRevision{}
+	}
+
+	respondWithJSON(w, http.StatusOK, revisions)
+}
+
+// GetQuestionRevisionHandler returns a single revision of a question
+func (c *Controller) GetQuestionRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodGet)
+
+	rev, statusCode, message := c.findRevision(r, mux.Vars(r)["rev"])
+	if rev == nil {
+		respondWithError(w, statusCode, message)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, rev)
+}
+
+// DiffQuestionRevisionsHandler compares two revisions of a question field by
+// field. from and to are revision numbers; to defaults to the latest.
+func (c *Controller) DiffQuestionRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodGet)
+
+	query := r.URL.Query()
+	from, statusCode, message := c.findRevision(r, query.Get("from"))
+	if from == nil {
+		respondWithError(w, statusCode, message)
+		return
+	}
+
+	var to *model.QuestionRevision
+	if v := query.Get("to"); v != "" {
+		to, statusCode, message = c.findRevision(r, v)
+		if to == nil {
+			respondWithError(w, statusCode, message)
+			return
+		}
+	} else {
+		revisions, err := c.revisions.List(r.Context(), from.QuestionID)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Error listing revisions", "err", err)
+			respondWithError(w, http.StatusInternalServerError, "Failed to retrieve revisions")
+			return
+		}
+		to = &revisions[len(revisions)-1]
+	}
+
+	respondWithJSON(w, http.StatusOK, model.RevisionDiff{
+		QuestionID: from.QuestionID,
+		From:       from.Revision,
+		To:         to.Revision,
+		Changes:    quiz.Diff(from.Question, to.Question),
+	})
+}
+
+// RevertQuestionHandler restores a question to the content of a prior
+// revision. The revert is itself recorded as a new revision.
+func (c *Controller) RevertQuestionHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodPost)
+
+	rev, statusCode, message := c.findRevision(r, mux.Vars(r)["rev"])
+	if rev == nil {
+		respondWithError(w, statusCode, message)
+		return
+	}
+
+	question := rev.Question
+	question.ID = rev.QuestionID
+	if errs := quiz.Validate(&question); errs != nil {
+		respondWithFieldErrors(w, errs)
+		return
+	}
+
+	ch := change{origin: originOf(r), action: model.RevisionReverted, revertedFrom: rev.Revision}
+	statusCode, message = c.updateQuestion(r.Context(), ch, question)
+	if statusCode != http.StatusOK {
+		respondWithError(w, statusCode, message)
+		return
+	}
+
+	c.respondWithQuestion(r.Context(), w, http.StatusOK, "Question reverted to revision "+strconv.Itoa(rev.Revision), question.ID)
+}
+
+// findRevision loads revision raw of the question named in the URL
+func (c *Controller) findRevision(r *http.Request, raw string) (*model.QuestionRevision, int, string) {
+	id, statusCode, message := c.questionID(r.Context(), mux.Vars(r)["id"])
+	if id == 0 {
+		return nil, statusCode, message
+	}
+
+	n, ok := revisionNumber(raw)
+	if !ok {
+		return nil, http.StatusBadRequest, "Revision must be a positive integer"
+	}
+
+	rev, err := c.revisions.Find(r.Context(), id, n)
+	if err == store.ErrNotFound {
+		return nil, http.StatusNotFound, "Revision not found"
+	} else if err != nil {
+		logging.FromContext(r.Context()).Error("Error loading revision", "err", err)
+		return nil, http.StatusInternalServerError, "Failed to retrieve revision"
+	}
+	return rev, http.StatusOK, ""
+}
@@ -0,0 +1,224 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/logging"
+	"github.com/AniketGodambe/mongoapi/model"
+	"github.com/AniketGodambe/mongoapi/store"
+	"github.com/gorilla/mux"
+)
+
+// confirmTokenTTL is how long a confirmation token stays valid.
+const confirmTokenTTL = 5 * time.Minute
+
+// confirmed checks the confirmation token sent with a destructive request, as
+// the confirm query parameter or the X-Confirm-Token header. Without one it
+// answers 428 with a new token to repeat the request with; with a bad one it
+// answers 400. Tokens are bound to the caller and action and signed rather
+// than stored, so any instance accepts them until they expire. It reports
+// whether the handler may go ahead.
+func (c *Controller) confirmed(w http.ResponseWriter, r *http.Request, action string) bool {
+	token := r.URL.Query().Get("confirm")
+	if token == "" {
+		token = r.Header.Get("X-Confirm-Token")
+	}
+	actor := actorOf(r)
+
+	if token == "" {
+		expires := time.Now().Add(confirmTokenTTL).Truncate(time.Second)
+		issued := c.confirmer.ConfirmToken(action, actor, expires)
+		w.WriteHeader(http.StatusPreconditionRequired)
+		json.NewEncoder(w).Encode(model.Response{
+			Message:    "Repeat the request with this confirm token to " + action,
+			StatusCode: http.StatusPreconditionRequired,
+			Data:       model.ConfirmToken{Token: issued, ExpiresAt: expires},
+		})
+		return false
+	}
+
+	if !c.confirmer.CheckConfirmToken(token, action, actor, time.Now()) {
+		respondWithError(w, http.StatusBadRequest, "Invalid or expired confirm token")
+		return false
+	}
+	return true
+}
+
+// trashContact moves a contact to the trash, returning how many were moved
+func (c *Controller) trashContact(ctx context.Context, o origin, id int) (int64, error) {
+	before, err := c.contacts.FindByID(ctx, id)
+	if err == store.ErrNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	at := time.Now()
+	deletedCount, err := c.contacts.Trash(ctx, id, at)
+	if err != nil || deletedCount == 0 {
+		return deletedCount, err
+	}
+
+	after := *before
+	after.DeletedAt = &at
+	c.audit(ctx, o, model.AuditDeleted, model.AuditContact, id, before, after)
+	return deletedCount, nil
+}
+
+// trashQuestion moves a question to the trash, returning how many were moved
+func (c *Controller) trashQuestion(ctx context.Context, o origin, id int) (int64, error) {
+	before, err := c.questions.FindByID(ctx, id)
+	if err == store.ErrNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	at := time.Now()
+	deletedCount, err := c.questions.Trash(ctx, id, at)
+	if err != nil || deletedCount == 0 {
+		return deletedCount, err
+	}
+
+	after := *before
+	after.DeletedAt = &at
+	c.audit(ctx, o, model.AuditDeleted, model.AuditQuestion, id, before, after)
+	return deletedCount, nil
+}
+
+// GetContactTrashHandler lists trashed contacts, most recently deleted first
+func (c *Controller) GetContactTrashHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodGet)
+
+	contacts, err := c.contacts.ListTrash(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error listing trashed contacts", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Database error!")
+		return
+	}
+	if contacts == nil {
+		contacts = []model.Contact{}
+	}
+	respondWithJSON(w, http.StatusOK, contacts)
+}
+
+// RestoreContactHandler takes a contact back out of the trash
+func (c *Controller) RestoreContactHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodPost)
+
+	id, statusCode, message := contactID(mux.Vars(r)["id"])
+	if id == 0 {
+		respondWithError(w, statusCode, message)
+		return
+	}
+
+	before, err := c.contacts.FindTrashed(r.Context(), id)
+	if err == store.ErrNotFound {
+		respondWithError(w, http.StatusNotFound, "Contact not found in trash")
+		return
+	} else if err != nil {
+		logging.FromContext(r.Context()).Error("Error loading trashed contact", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Database error!")
+		return
+	}
+
+	restored, err := c.contacts.Restore(r.Context(), id)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error restoring contact", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Database error!")
+		return
+	}
+	if restored == 0 {
+		respondWithError(w, http.StatusNotFound, "Contact not found in trash")
+		return
+	}
+
+	contact, err := c.contacts.FindByID(r.Context(), id)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error loading contact", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Database error!")
+		return
+	}
+	c.audit(r.Context(), originOf(r), model.AuditRestored, model.AuditContact, id, before, contact)
+	respondWithJSON(w, http.StatusOK, contact)
+}
+
+// GetQuestionTrashHandler lists trashed questions, most recently deleted first
+func (c *Controller) GetQuestionTrashHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodGet)
+
+	questions, err := c.questions.ListTrash(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error listing trashed questions", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Database error!")
+		return
+	}
+	if questions == nil {
+		questions = []model.Question{}
+	}
+	respondWithJSON(w, http.StatusOK, questions)
+}
+
+// RestoreQuestionHandler takes a question back out of the trash
+func (c *Controller) RestoreQuestionHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodPost)
+
+	id, statusCode, message := c.questionID(r.Context(), mux.Vars(r)["id"])
+	if id == 0 {
+		respondWithError(w, statusCode, message)
+		return
+	}
+
+	before, err := c.questions.FindTrashed(r.Context(), id)
+	if err == store.ErrNotFound {
+		respondWithError(w, http.StatusNotFound, "Question not found in trash")
+		return
+	} else if err != nil {
+		logging.FromContext(r.Context()).Error("Error loading trashed question", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Database error!")
+		return
+	}
+
+	restored, err := c.questions.Restore(r.Context(), id)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error restoring question", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Database error!")
+		return
+	}
+	if restored == 0 {
+		respondWithError(w, http.StatusNotFound, "Question not found in trash")
+		return
+	}
+	c.auditQuestion(r.Context(), change{origin: originOf(r), action: model.AuditRestored}, id, before)
+
+	c.respondWithQuestion(r.Context(), w, http.StatusOK, "Question restored", id)
+}
+
+// PurgeTrashHandler permanently removes everything that has been in the trash
+// for longer than the configured retention, or the whole trash when the
+// retention is zero. Emptying the whole trash needs a confirm token, as
+// deleting every contact does.
+func (c *Controller) PurgeTrashHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodPost)
+
+	if c.retention == 0 && !c.confirmed(w, r, "purge the whole trash") {
+		return
+	}
+
+	contacts, questions, err := store.PurgeTrash(r.Context(), store.Stores{Contacts: c.contacts, Questions: c.questions}, c.retention)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error purging trash", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to purge trash")
+		return
+	}
+
+	purged := map[string]int64{
+		"contacts_purged":  contacts,
+		"questions_purged": questions,
+	}
+	c.audit(r.Context(), originOf(r), model.AuditPurged, model.AuditTrash, 0, nil, purged)
+	respondWithJSON(w, http.StatusOK, purged)
+}
@@ -4,42 +4,50 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
+	"net/mail"
 	"regexp"
+	"strings"
+	"time"
 
+	"github.com/AniketGodambe/mongoapi/language"
+	"github.com/AniketGodambe/mongoapi/logging"
 	"github.com/AniketGodambe/mongoapi/model"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
+	"github.com/AniketGodambe/mongoapi/store"
+	"github.com/gorilla/mux"
 )
 
-// getAllContacts fetches all contacts from the database
-func getAllContacts() ([]model.Contact, error) {
-	var contacts []model.Contact
-	cursor, err := ContactsCollection.Find(context.Background(), bson.D{{}})
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(context.Background())
-
-	for cursor.Next(context.Background()) {
-		var contact model.Contact
-		if err := cursor.Decode(&contact); err != nil {
-			return nil, err
-		}
-		contacts = append(contacts, contact)
-	}
-
-	return contacts, nil
+// getAllContacts fetches the contacts matching query from the database
+func (c *Controller) getAllContacts(ctx context.Context, query store.ContactQuery) ([]model.Contact, error) {
+	return c.contacts.List(ctx, query)
 }
 
-// GetAllContactHandler handles the API request to fetch all contacts
-func GetAllContactHandler(w http.ResponseWriter, r *http.Request) {
+// GetAllContactHandler handles the API request to fetch all contacts,
+// optionally filtered by ?channel= and ?language=
+func (c *Controller) GetAllContactHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	contacts, err := getAllContacts()
+	var query store.ContactQuery
+	if v := r.URL.Query().Get("channel"); v != "" {
+		channel, ok := normalizeChannelName(v)
+		if !ok {
+			respondWithError(w, http.StatusBadRequest, "Unknown channel "+v)
+			return
+		}
+		query.Channel = channel
+	}
+	if v := r.URL.Query().Get("language"); v != "" {
+		code, ok := language.Normalize(v)
+		if !ok {
+			respondWithError(w, http.StatusBadRequest, "Unknown language "+v)
+			return
+		}
+		query.Language = code
+	}
+
+	contacts, err := c.getAllContacts(r.Context(), query)
 	if err != nil {
+		logging.FromContext(r.Context()).Error("Error listing contacts", "err", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(model.Response{
 			Message:    "Failed to retrieve contacts",
@@ -56,37 +64,133 @@ func GetAllContactHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func createOneContact(contact model.Contact) (bool, string, int) {
-	var existingContact model.Contact
-	err := ContactsCollection.FindOne(context.TODO(), bson.M{"mobile": contact.Mobile}).Decode(&existingContact)
-	if err == nil {
-		return false, "Mobile number already exists!", 0
-	} else if err != mongo.ErrNoDocuments {
-		log.Println("Error checking existing contact:", err)
-		return false, "Database error!", 0
+var mobileRegex = regexp.MustCompile(`^\d{10}$`)
+
+// phoneRegex loosely matches the phone numbers used as channel details
+var phoneRegex = regexp.MustCompile(`^\+?\d[\d ()-]{5,19}$`)
+
+var channelNames = []string{model.ChannelPhone, model.ChannelEmail, model.ChannelWhatsApp, model.ChannelSMS}
+
+// normalizeChannelName maps a case-insensitive channel type to its canonical spelling
+func normalizeChannelName(name string) (string, bool) {
+	for _, known := range channelNames {
+		if strings.EqualFold(strings.TrimSpace(name), known) {
+			return known, true
+		}
 	}
+	return "", false
+}
 
-	count, err := ContactsCollection.CountDocuments(context.TODO(), bson.M{})
-	if err != nil {
-		log.Println("Error counting documents:", err)
-		return false, "Failed to generate user ID!", 0
+// maxAge is the oldest age a contact may have
+const maxAge = 150
+
+// validateContact checks a new contact and returns the reason it is rejected,
+// or "" when it is valid. Channels and languages are normalized in place.
+func validateContact(contact *model.Contact) string {
+	// Validate mobile number (must be exactly 10 digits)
+	if !mobileRegex.MatchString(contact.Mobile) {
+		return "Mobile number must be exactly 10 digits!"
+	}
+	if message := validateAge(contact.Age); message != "" {
+		return message
+	}
+	return validatePreferences(contact)
+}
+
+func validateAge(age int) string {
+	if age < 0 || age > maxAge {
+		return fmt.Sprintf("Age must be between 0 and %d", maxAge)
+	}
+	return ""
+}
+
+// validatePreferences checks the preferred channels and languages. Channel
+// types get their canonical spelling and IDs, languages become ISO 639-1 codes.
+func validatePreferences(contact *model.Contact) string {
+	for i := range contact.PreferredChannel {
+		ch := &contact.PreferredChannel[i]
+		name, ok := normalizeChannelName(ch.ChannelName)
+		if !ok {
+			return fmt.Sprintf("Unknown channel %q, expected one of %s", ch.ChannelName, strings.Join(channelNames, ", "))
+		}
+		ch.ID = i
+		ch.ChannelName = name
+		ch.ChannelDetails = strings.TrimSpace(ch.ChannelDetails)
+
+		switch name {
+		case model.ChannelEmail:
+			addr, err := mail.ParseAddress(ch.ChannelDetails)
+			if err != nil || addr.Address != ch.ChannelDetails {
+				return fmt.Sprintf("Channel %d: %q is not a valid email address", i, ch.ChannelDetails)
+			}
+		default:
+			if !phoneRegex.MatchString(ch.ChannelDetails) {
+				return fmt.Sprintf("Channel %d: %q is not a valid phone number", i, ch.ChannelDetails)
+			}
+		}
 	}
 
-	contact.ID = int(count) + 1
+	seen := map[string]bool{}
+	languages := contact.PreferredLanguage[:0]
+	for _, l := range contact.PreferredLanguage {
+		code, ok := language.Normalize(l)
+		if !ok {
+			return fmt.Sprintf("Unknown language %q, expected an ISO 639-1 code", l)
+		}
+		if !seen[code] {
+			seen[code] = true
+			languages = append(languages, code)
+		}
+	}
+	contact.PreferredLanguage = languages
+	return ""
+}
 
-	result, err := ContactsCollection.InsertOne(context.TODO(), contact)
+// mobileTaken is the conflict message for a mobile owned by existing. A
+// trashed contact keeps its mobile until purged, so the message points to
+// the restore endpoint instead of leaving the caller to wait out the
+// retention period.
+func mobileTaken(existing *model.Contact) string {
+	if existing.DeletedAt != nil {
+		return fmt.Sprintf("Mobile number belongs to trashed contact %d; restore it with POST /api/v2/contacts/%d/restore", existing.ID, existing.ID)
+	}
+	return "Mobile number already exists!"
+}
+
+// createOneContact inserts a validated contact and returns the HTTP status,
+// a message and the new ID
+func (c *Controller) createOneContact(ctx context.Context, o origin, contact model.Contact) (int, string, int) {
+	existing, err := c.contacts.FindByMobile(ctx, contact.Mobile)
+	if err == nil {
+		return http.StatusConflict, mobileTaken(existing), 0
+	} else if err != store.ErrNotFound {
+		logging.FromContext(ctx).Error("Error checking existing contact", "err", err)
+		return http.StatusInternalServerError, "Database error!", 0
+	}
+
+	contact.DeletedAt = nil
+	contact.ID, err = c.ids.Next(ctx, store.ContactsSequence)
 	if err != nil {
-		log.Println("Error inserting contact:", err)
-		return false, "Failed to insert contact!", 0
+		logging.FromContext(ctx).Error("Error generating contact ID", "err", err)
+		return http.StatusInternalServerError, "Failed to generate user ID!", 0
+	}
+
+	err = c.contacts.Insert(ctx, contact)
+	if err == store.ErrDuplicate {
+		return http.StatusConflict, "Mobile number already exists!", 0
+	} else if err != nil {
+		logging.FromContext(ctx).Error("Error inserting contact", "err", err)
+		return http.StatusInternalServerError, "Failed to insert contact!", 0
 	}
 
-	fmt.Println("Inserted ID:", result.InsertedID)
+	logging.FromContext(ctx).Info("Contact inserted", "id", contact.ID)
+	c.audit(ctx, o, model.AuditCreated, model.AuditContact, contact.ID, nil, contact)
 
-	return true, "Contact inserted successfully!", contact.ID
+	return http.StatusCreated, "Contact inserted successfully!", contact.ID
 }
 
 // CreateContactHandler handles API request to add a new contact
-func CreateContactHandler(w http.ResponseWriter, r *http.Request) {
+func (c *Controller) CreateContactHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Methods", "POST")
 
@@ -110,23 +214,16 @@ func CreateContactHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate mobile number (must be exactly 10 digits)
-	mobileRegex := regexp.MustCompile(`^\d{10}$`)
-	if !mobileRegex.MatchString(newContact.Mobile) {
+	if message := validateContact(&newContact); message != "" {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(model.Response{
-			Message:    "Mobile number must be exactly 10 digits!",
+			Message:    message,
 			StatusCode: http.StatusBadRequest,
 		})
 		return
 	}
 
-	success, message, userID := createOneContact(newContact)
-
-	statusCode := http.StatusCreated
-	if !success {
-		statusCode = http.StatusConflict
-	}
+	statusCode, message, userID := c.createOneContact(r.Context(), originOf(r), newContact)
 
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(model.Response{
@@ -136,31 +233,29 @@ func CreateContactHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// deleteOneContact removes a contact by ID from the database
-func deleteOneContact(contactId string) (bool, string, int64) {
-	id, err := primitive.ObjectIDFromHex(contactId)
-	if err != nil {
-		log.Println("Invalid contact ID format:", err)
-		return false, "Invalid contact ID format!", 0
+// deleteOneContact moves a contact to the trash by its public ID
+func (c *Controller) deleteOneContact(ctx context.Context, o origin, contactId string) (int, string, int64) {
+	id, statusCode, message := contactID(contactId)
+	if id == 0 {
+		return statusCode, message, 0
 	}
 
-	filter := bson.M{"_id": id}
-	result, err := ContactsCollection.DeleteOne(context.Background(), filter)
+	deletedCount, err := c.trashContact(ctx, o, id)
 	if err != nil {
-		log.Println("Error deleting contact:", err)
-		return false, "Database error!", 0
+		logging.FromContext(ctx).Error("Error deleting contact", "err", err)
+		return http.StatusInternalServerError, "Database error!", 0
 	}
 
-	if result.DeletedCount == 0 {
-		return false, "Contact not found!", 0
+	if deletedCount == 0 {
+		return http.StatusNotFound, "Contact not found!", 0
 	}
 
-	fmt.Println("Contact deleted successfully!", result.DeletedCount)
-	return true, "Contact deleted successfully!", result.DeletedCount
+	logging.FromContext(ctx).Info("Contact moved to trash", "id", id)
+	return http.StatusOK, "Contact moved to trash!", deletedCount
 }
 
 // DeleteOneContactHandler handles API requests to delete a contact
-func DeleteOneContactHandler(w http.ResponseWriter, r *http.Request) {
+func (c *Controller) DeleteOneContactHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Methods", "DELETE")
 
@@ -184,16 +279,7 @@ func DeleteOneContactHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	success, message, deletedCount := deleteOneContact(contactId)
-
-	statusCode := http.StatusOK
-	if !success {
-		if deletedCount == 0 {
-			statusCode = http.StatusNotFound
-		} else {
-			statusCode = http.StatusInternalServerError
-		}
-	}
+	statusCode, message, deletedCount := c.deleteOneContact(r.Context(), originOf(r), contactId)
 
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(model.Response{
@@ -203,20 +289,23 @@ func DeleteOneContactHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func deleteAllContact() (int64, error) {
-	result, err := ContactsCollection.DeleteMany(context.Background(), bson.D{{}})
-	if err != nil {
-		return 0, err
-	}
-	return result.DeletedCount, nil
+func (c *Controller) deleteAllContact(ctx context.Context) (int64, error) {
+	return c.contacts.TrashAll(ctx, time.Now())
 }
 
-func DeleteAllContactHandler(w http.ResponseWriter, r *http.Request) {
+// DeleteAllContactHandler moves every contact to the trash. The first call
+// only issues a confirmation token; repeating it with that token deletes.
+func (c *Controller) DeleteAllContactHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Methods", "DELETE")
 
-	deletedCount, err := deleteAllContact()
+	if !c.confirmed(w, r, "delete all contacts") {
+		return
+	}
+
+	deletedCount, err := c.deleteAllContact(r.Context())
 	if err != nil {
+		logging.FromContext(r.Context()).Error("Error deleting all contacts", "err", err)
 		response := model.Response{
 			Message:    "Failed to delete contacts",
 			StatusCode: http.StatusInternalServerError,
@@ -226,9 +315,10 @@ func DeleteAllContactHandler(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(response)
 		return
 	}
+	c.audit(r.Context(), originOf(r), model.AuditDeleteAll, model.AuditContact, 0, nil, map[string]int64{"count": deletedCount})
 
 	response := model.Response{
-		Message:    "All contacts deleted successfully!",
+		Message:    "All contacts moved to trash!",
 		StatusCode: http.StatusOK,
 		Data:       map[string]int64{"deletedCount": deletedCount},
 	}
@@ -236,46 +326,139 @@ func DeleteAllContactHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-func UpdateContactHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Methods", "PUT")
+// UpdateContactHandler is the legacy full update: it always sets the name and
+// age, and the channels and languages when they are sent
+func (c *Controller) UpdateContactHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodPut)
 
 	var contact model.Contact
 	err := json.NewDecoder(r.Body).Decode(&contact)
 	if err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
-	if contact.ID == 0 {
-		http.Error(w, "ID is required", http.StatusBadRequest)
+	if contact.ID <= 0 {
+		respondWithError(w, http.StatusBadRequest, "A positive ID is required")
 		return
 	}
 
-	updatedCount, err := updateContact(contact.ID, contact.ContactName, contact.Age)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	patch := model.ContactPatch{ContactName: &contact.ContactName, Age: &contact.Age}
+	if contact.PreferredChannel != nil {
+		patch.PreferredChannel = &contact.PreferredChannel
+	}
+	if contact.PreferredLanguage != nil {
+		patch.PreferredLanguage = &contact.PreferredLanguage
+	}
+	c.respondWithPatch(r.Context(), w, originOf(r), contact.ID, patch)
+}
+
+// PatchContactHandler updates any subset of a contact's fields
+func (c *Controller) PatchContactHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodPatch)
+
+	id, statusCode, message := contactID(mux.Vars(r)["id"])
+	if id == 0 {
+		respondWithError(w, statusCode, message)
 		return
 	}
 
-	response := fmt.Sprintf("Contact with ID %d updated successfully!", contact.ID)
-	if updatedCount == 0 {
-		response = "No contact was updated, possibly wrong ID."
+	var patch model.ContactPatch
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patch); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if patch.Empty() {
+		respondWithError(w, http.StatusBadRequest, "No fields to update")
+		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(response))
+	c.respondWithPatch(r.Context(), w, originOf(r), id, patch)
 }
 
-func updateContact(contactID int, name string, age int) (int64, error) {
-	filter := bson.M{"_id": contactID}
-	update := bson.M{"$set": bson.M{"contact_name": name, "age": age}}
+// respondWithPatch validates and applies patch, answering with the updated contact
+func (c *Controller) respondWithPatch(ctx context.Context, w http.ResponseWriter, o origin, id int, patch model.ContactPatch) {
+	if message := validatePatch(&patch); message != "" {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
 
-	result, err := ContactsCollection.UpdateOne(context.Background(), filter, update)
-	if err != nil {
-		log.Println("Error updating contact:", err)
-		return 0, err
+	updated, statusCode, message := c.patchContact(ctx, o, id, patch)
+	if updated == nil {
+		respondWithError(w, statusCode, message)
+		return
+	}
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(model.Response{
+		Message:    message,
+		StatusCode: statusCode,
+		Data:       updated,
+	})
+}
+
+// validatePatch checks the fields the patch sets, normalizing preferences in place
+func validatePatch(patch *model.ContactPatch) string {
+	if patch.Mobile != nil && !mobileRegex.MatchString(*patch.Mobile) {
+		return "Mobile number must be exactly 10 digits!"
+	}
+	if patch.Age != nil {
+		if message := validateAge(*patch.Age); message != "" {
+			return message
+		}
 	}
 
-	return result.ModifiedCount, nil
+	var prefs model.Contact
+	if patch.PreferredChannel != nil {
+		prefs.PreferredChannel = *patch.PreferredChannel
+	}
+	if patch.PreferredLanguage != nil {
+		prefs.PreferredLanguage = *patch.PreferredLanguage
+	}
+	if message := validatePreferences(&prefs); message != "" {
+		return message
+	}
+	if patch.PreferredChannel != nil {
+		patch.PreferredChannel = &prefs.PreferredChannel
+	}
+	if patch.PreferredLanguage != nil {
+		patch.PreferredLanguage = &prefs.PreferredLanguage
+	}
+	return ""
+}
+
+func (c *Controller) patchContact(ctx context.Context, o origin, id int, patch model.ContactPatch) (*model.Contact, int, string) {
+	before, err := c.contacts.FindByID(ctx, id)
+	if err == store.ErrNotFound {
+		return nil, http.StatusNotFound, "Contact not found!"
+	} else if err != nil {
+		logging.FromContext(ctx).Error("Error loading contact", "err", err)
+		return nil, http.StatusInternalServerError, "Database error!"
+	}
+
+	if patch.Mobile != nil {
+		existing, err := c.contacts.FindByMobile(ctx, *patch.Mobile)
+		if err == nil && existing.ID != id {
+			return nil, http.StatusConflict, mobileTaken(existing)
+		} else if err != nil && err != store.ErrNotFound {
+			logging.FromContext(ctx).Error("Error checking existing contact", "err", err)
+			return nil, http.StatusInternalServerError, "Database error!"
+		}
+	}
+
+	updated, err := c.contacts.Patch(ctx, id, patch)
+	switch err {
+	case nil:
+		c.audit(ctx, o, model.AuditUpdated, model.AuditContact, id, before, updated)
+		return updated, http.StatusOK, "Contact updated successfully!"
+	case store.ErrNotFound:
+		return nil, http.StatusNotFound, "Contact not found!"
+	case store.ErrDuplicate:
+		return nil, http.StatusConflict, "Mobile number already exists!"
+	default:
+		logging.FromContext(ctx).Error("Error updating contact", "err", err)
+		return nil, http.StatusInternalServerError, "Failed to update contact!"
+	}
 }
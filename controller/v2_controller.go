@@ -0,0 +1,270 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/AniketGodambe/mongoapi/logging"
+	"github.com/AniketGodambe/mongoapi/model"
+	"github.com/AniketGodambe/mongoapi/quiz"
+	"github.com/AniketGodambe/mongoapi/store"
+	"github.com/gorilla/mux"
+)
+
+// V2 resource paths, used for Location headers.
+const (
+	v2ContactsPath  = "/api/v2/contacts"
+	v2QuestionsPath = "/api/v2/questions"
+)
+
+// CreateContactV2Handler creates a contact and answers 201 with its
+// Location and the stored representation
+func (c *Controller) CreateContactV2Handler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodPost)
+
+	var contact model.Contact
+	if err := json.NewDecoder(r.Body).Decode(&contact); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if message := validateContact(&contact); message != "" {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	statusCode, message, id := c.createOneContact(r.Context(), originOf(r), contact)
+	if statusCode != http.StatusCreated {
+		respondWithError(w, statusCode, message)
+		return
+	}
+
+	contact.ID = id
+	w.Header().Set("Location", v2ContactsPath+"/"+strconv.Itoa(id))
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(model.Response{
+		Message:    message,
+		StatusCode: statusCode,
+		Data:       contact,
+	})
+}
+
+// GetContactHandler returns a single contact
+func (c *Controller) GetContactHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodGet)
+
+	id, statusCode, message := contactID(mux.Vars(r)["id"])
+	if id == 0 {
+		respondWithError(w, statusCode, message)
+		return
+	}
+
+	contact, err := c.contacts.FindByID(r.Context(), id)
+	if err == store.ErrNotFound {
+		respondWithError(w, http.StatusNotFound, "Contact not found!")
+		return
+	} else if err != nil {
+		logging.FromContext(r.Context()).Error("Error loading contact", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Database error!")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, contact)
+}
+
+// DeleteContactHandler moves a contact to the trash and answers 204
+func (c *Controller) DeleteContactHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodDelete)
+
+	id, statusCode, message := contactID(mux.Vars(r)["id"])
+	if id == 0 {
+		respondWithError(w, statusCode, message)
+		return
+	}
+
+	deletedCount, err := c.trashContact(r.Context(), originOf(r), id)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error deleting contact", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Database error!")
+		return
+	}
+	if deletedCount == 0 {
+		respondWithError(w, http.StatusNotFound, "Contact not found!")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateQuestionV2Handler creates a question and answers 201 with its
+// Location and the stored representation
+func (c *Controller) CreateQuestionV2Handler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodPost)
+
+	var question model.Question
+	if err := json.NewDecoder(r.Body).Decode(&question); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if errs := quiz.Validate(&question); errs != nil {
+		respondWithFieldErrors(w, errs)
+		return
+	}
+
+	statusCode, message, id := c.createOneQuestion(r.Context(), originOf(r), question)
+	if statusCode != http.StatusCreated {
+		respondWithError(w, statusCode, message)
+		return
+	}
+
+	w.Header().Set("Location", v2QuestionsPath+"/"+strconv.Itoa(id))
+	c.respondWithQuestion(r.Context(), w, statusCode, message, id)
+}
+
+// GetQuestionHandler returns a single question
+func (c *Controller) GetQuestionHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodGet)
+
+	id, statusCode, message := c.questionID(r.Context(), mux.Vars(r)["id"])
+	if id == 0 {
+		respondWithError(w, statusCode, message)
+		return
+	}
+
+	c.respondWithQuestion(r.Context(), w, http.StatusOK, "Success", id)
+}
+
+// ReplaceQuestionHandler overwrites a question with the request body. An ID
+// in the body must match the one in the path.
+func (c *Controller) ReplaceQuestionHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodPut)
+
+	id, statusCode, message := c.questionID(r.Context(), mux.Vars(r)["id"])
+	if id == 0 {
+		respondWithError(w, statusCode, message)
+		return
+	}
+
+	var question model.Question
+	if err := json.NewDecoder(r.Body).Decode(&question); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if question.ID != 0 && question.ID != id {
+		respondWithError(w, http.StatusBadRequest, "Question ID in body does not match the URL")
+		return
+	}
+	question.ID = id
+	if errs := quiz.Validate(&question); errs != nil {
+		respondWithFieldErrors(w, errs)
+		return
+	}
+
+	statusCode, message = c.updateQuestion(r.Context(), change{origin: originOf(r), action: model.RevisionUpdated}, question)
+	if statusCode != http.StatusOK {
+		respondWithError(w, statusCode, message)
+		return
+	}
+
+	c.respondWithQuestion(r.Context(), w, statusCode, message, id)
+}
+
+// DeleteQuestionV2Handler moves a question to the trash and answers 204
+func (c *Controller) DeleteQuestionV2Handler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodDelete)
+
+	id, statusCode, message := c.questionID(r.Context(), mux.Vars(r)["id"])
+	if id == 0 {
+		respondWithError(w, statusCode, message)
+		return
+	}
+
+	deletedCount, err := c.trashQuestion(r.Context(), originOf(r), id)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error deleting question", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Database error!")
+		return
+	}
+	if deletedCount == 0 {
+		respondWithError(w, http.StatusNotFound, "Question not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetQuestionVisibilityHandler sets the hidden flag to the value in the
+// request body, which makes it safe to repeat unlike the v1 toggle
+func (c *Controller) SetQuestionVisibilityHandler(w http.ResponseWriter, r *http.Request) {
+	setHeaders(w, http.MethodPut)
+
+	id, statusCode, message := c.questionID(r.Context(), mux.Vars(r)["id"])
+	if id == 0 {
+		respondWithError(w, statusCode, message)
+		return
+	}
+
+	var request struct {
+		Hidden *bool `json:"hidden"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if request.Hidden == nil {
+		respondWithError(w, http.StatusBadRequest, "hidden is required")
+		return
+	}
+
+	existing, err := c.questions.FindByID(r.Context(), id)
+	if err == store.ErrNotFound {
+		respondWithError(w, http.StatusNotFound, "Question not found")
+		return
+	} else if err != nil {
+		logging.FromContext(r.Context()).Error("Error loading question", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Database error!")
+		return
+	}
+
+	matched, err := c.questions.SetHidden(r.Context(), id, *request.Hidden)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error updating question visibility", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to update question visibility!")
+		return
+	}
+	if matched == 0 {
+		respondWithError(w, http.StatusNotFound, "Question not found")
+		return
+	}
+
+	action := model.RevisionShown
+	if *request.Hidden {
+		action = model.RevisionHidden
+	}
+	ch := change{origin: originOf(r), action: action}
+	c.recordRevision(r.Context(), ch, id, existing)
+	c.auditQuestion(r.Context(), ch, id, existing)
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"id": id, "hidden": *request.Hidden})
+}
+
+// respondWithQuestion loads the question and writes it with the given status
+func (c *Controller) respondWithQuestion(ctx context.Context, w http.ResponseWriter, statusCode int, message string, id int) {
+	question, err := c.questions.FindByID(ctx, id)
+	if err == store.ErrNotFound {
+		respondWithError(w, http.StatusNotFound, "Question not found")
+		return
+	} else if err != nil {
+		logging.FromContext(ctx).Error("Error loading question", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Database error!")
+		return
+	}
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(model.Response{
+		Message:    message,
+		StatusCode: statusCode,
+		Data:       question,
+	})
+}
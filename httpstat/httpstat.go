@@ -0,0 +1,71 @@
+// Package httpstat records what a handler wrote and which route served it,
+// for the middlewares that log and count requests.
+package httpstat
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Unmatched is the route of requests no mux route matched.
+const Unmatched = "unmatched"
+
+// Recorder wraps a ResponseWriter, remembering the status and size of the
+// response written through it.
+type Recorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// NewRecorder returns a Recorder writing to w.
+func NewRecorder(w http.ResponseWriter) *Recorder {
+	return &Recorder{ResponseWriter: w}
+}
+
+func (s *Recorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *Recorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (s *Recorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// Status returns the status written, or 200 if the handler wrote nothing,
+// as net/http then sends.
+func (s *Recorder) Status() int {
+	if s.status == 0 {
+		return http.StatusOK
+	}
+	return s.status
+}
+
+// Bytes returns how many body bytes were written.
+func (s *Recorder) Bytes() int64 {
+	return s.bytes
+}
+
+// Route returns the path template of the mux route r matched, so
+// /contacts/1 and /contacts/2 share one name, or Unmatched.
+func Route(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return Unmatched
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/httpstat"
+)
+
+// HeaderRequestID carries the request ID in both directions.
+const HeaderRequestID = "X-Request-ID"
+
+// requestIDPattern limits the IDs taken from callers to ones that are safe to
+// log and echo back.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type requestIDKey struct{}
+
+// RequestID returns the ID the middleware assigned to r.
+func RequestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID returns 16 random bytes in hex.
+func newRequestID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// Middleware gives every request an ID, taken from a well-formed
+// X-Request-ID header or generated, and echoes it in the response. Handlers
+// find a logger tagged with the ID through FromContext. Once the response is
+// written it logs one access line.
+func Middleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			id := r.Header.Get(HeaderRequestID)
+			if !requestIDPattern.MatchString(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(HeaderRequestID, id)
+
+			reqLogger := logger.With("request_id", id)
+			ctx := context.WithValue(NewContext(r.Context(), reqLogger), requestIDKey{}, id)
+			rec := httpstat.NewRecorder(w)
+			next.ServeHTTP(rec, r.WithContext(ctx))
+
+			reqLogger.LogAttrs(ctx, accessLevel(rec.Status()), "request",
+				slog.String("method", r.Method),
+				slog.String("route", httpstat.Route(r)),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", rec.Status()),
+				slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+				slog.Int64("bytes", rec.Bytes()),
+				slog.String("remote", r.RemoteAddr),
+			)
+		})
+	}
+}
+
+// accessLevel logs server errors as errors and everything else as info.
+func accessLevel(status int) slog.Level {
+	if status >= http.StatusInternalServerError {
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
@@ -0,0 +1,173 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+var generatedID = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+func TestMiddlewareRequestID(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		// keep is whether the caller's ID is used rather than a new one
+		keep bool
+	}{
+		{name: "uuid", header: "3f2b8c1e-9a47-4d2b-8f0e-1c2d3e4f5a6b", keep: true},
+		{name: "all allowed characters", header: "Az09._:-", keep: true},
+		{name: "longest", header: strings.Repeat("a", 128), keep: true},
+		{name: "missing"},
+		{name: "too long", header: strings.Repeat("a", 129)},
+		{name: "space", header: "abc def"},
+		{name: "log injection", header: "abc\nlevel=ERROR msg=forged"},
+		{name: "quote", header: `abc"`},
+		{name: "non ascii", header: "é"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var seen, logged string
+			var logs bytes.Buffer
+			h := Middleware(slog.New(slog.NewJSONHandler(&logs, nil)))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = RequestID(r)
+				FromContext(r.Context()).Info("handled")
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				req.Header.Set(HeaderRequestID, tc.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			echoed := rec.Header().Get(HeaderRequestID)
+			if tc.keep && echoed != tc.header {
+				t.Errorf("echoed ID %q, want the caller's %q", echoed, tc.header)
+			}
+			if !tc.keep && !generatedID.MatchString(echoed) {
+				t.Errorf("echoed ID %q, want a generated one", echoed)
+			}
+			if seen != echoed {
+				t.Errorf("handler saw ID %q, response carries %q", seen, echoed)
+			}
+			for _, line := range decodeLines(t, &logs) {
+				logged, _ = line["request_id"].(string)
+				if logged != echoed {
+					t.Errorf("%q line logged request_id %q, want %q", line["msg"], logged, echoed)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestIDsDiffer(t *testing.T) {
+	h := Middleware(slog.New(slog.NewTextHandler(io.Discard, nil)))(http.NotFoundHandler())
+	seen := map[string]bool{}
+	for range 100 {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		id := rec.Header().Get(HeaderRequestID)
+		if seen[id] {
+			t.Fatalf("request ID %q generated twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestMiddlewareAccessLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		handler http.HandlerFunc
+		want    map[string]any
+	}{
+		{
+			name:   "matched route",
+			target: "/contacts/42?verbose=1",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				io.WriteString(w, "hello")
+				w.WriteHeader(http.StatusTeapot) // ignored, as net/http does
+			},
+			want: map[string]any{"level": "INFO", "route": "/contacts/{id}", "path": "/contacts/42", "status": 201.0, "bytes": 5.0},
+		},
+		{
+			name:    "implicit 200",
+			target:  "/contacts/7",
+			handler: func(w http.ResponseWriter, r *http.Request) {},
+			want:    map[string]any{"level": "INFO", "route": "/contacts/{id}", "status": 200.0, "bytes": 0.0},
+		},
+		{
+			name:   "server error",
+			target: "/contacts/7",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "boom", http.StatusInternalServerError)
+			},
+			want: map[string]any{"level": "ERROR", "status": 500.0, "bytes": 5.0},
+		},
+		{
+			name:    "unmatched",
+			target:  "/nowhere",
+			handler: func(w http.ResponseWriter, r *http.Request) {},
+			want:    map[string]any{"level": "INFO", "route": "unmatched", "path": "/nowhere", "status": 404.0},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&logs, nil))
+			router := mux.NewRouter()
+			router.Use(Middleware(logger))
+			router.HandleFunc("/contacts/{id}", tc.handler).Methods(http.MethodGet)
+			router.NotFoundHandler = Middleware(logger)(http.NotFoundHandler())
+
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			lines := decodeLines(t, &logs)
+			if len(lines) != 1 {
+				t.Fatalf("logged %d lines, want 1 access line", len(lines))
+			}
+			line := lines[0]
+			want := map[string]any{"msg": "request", "method": "GET", "remote": "192.0.2.1:1234"}
+			for k, v := range tc.want {
+				want[k] = v
+			}
+			for k, v := range want {
+				if line[k] != v {
+					t.Errorf("%s = %v, want %v", k, line[k], v)
+				}
+			}
+			if latency, ok := line["latency_ms"].(float64); !ok || latency < 0 {
+				t.Errorf("latency_ms = %v", line["latency_ms"])
+			}
+			if id, _ := line["request_id"].(string); !generatedID.MatchString(id) {
+				t.Errorf("request_id = %v", line["request_id"])
+			}
+		})
+	}
+}
+
+func decodeLines(t *testing.T, logs *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	dec := json.NewDecoder(logs)
+	for dec.More() {
+		var line map[string]any
+		if err := dec.Decode(&line); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -1,24 +1,224 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
-	"github.com/AniketGodambe/mongoapi/controller"
+	"github.com/AniketGodambe/mongoapi/auth"
+	"github.com/AniketGodambe/mongoapi/config"
+	"github.com/AniketGodambe/mongoapi/logging"
+	"github.com/AniketGodambe/mongoapi/metrics"
+	"github.com/AniketGodambe/mongoapi/model"
 	"github.com/AniketGodambe/mongoapi/router"
+	"github.com/AniketGodambe/mongoapi/store"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Server timeouts. Writes get longer than reads so large exports can finish;
+// shutdownTimeout is how long in-flight requests get to drain on SIGTERM.
+const (
+	readHeaderTimeout = 5 * time.Second
+	readTimeout       = 30 * time.Second
+	writeTimeout      = 60 * time.Second
+	idleTimeout       = 120 * time.Second
+	shutdownTimeout   = 20 * time.Second
+)
+
+// Backoff between attempts to prepare MongoDB at startup.
+const (
+	retryInitial = time.Second
+	retryMax     = 30 * time.Second
 )
 
 func main() {
+	configPath := flag.String("config", "", "path to a YAML or JSON config file (default $"+config.EnvConfigFile+")")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+	logger.Info("Loaded configuration", "config", cfg.Redacted())
+
+	var stores store.Stores
+	var client *mongo.Client
+	var mongoHealth *store.MongoHealth
+	switch cfg.Store {
+	case config.StoreMongo:
+		db, err := store.Connect(cfg.Mongo)
+		if err != nil {
+			logger.Error("Error initializing MongoDB", "err", err)
+			os.Exit(1)
+		}
+		client = db.Client()
+		mongoHealth = store.NewMongoHealth(db, cfg.Mongo)
+		stores = store.NewMongoStores(db, cfg.Mongo)
+		stores.Health = mongoHealth
+	case config.StoreMemory:
+		stores = store.NewMemoryStores()
+	}
+
+	authenticator := auth.NewAuthenticator(cfg.Auth.JWTSecret, cfg.Auth.Keys())
+	if !authenticator.Enabled() {
+		logger.Warn("No JWT secret or API keys configured: protected routes will reject every request")
+	}
+
+	r := router.Router(stores, authenticator, cfg.Retention(), metrics.NewRegistry(), logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	controller.InitDB()
+	// The server listens while MongoDB is prepared; the trash is only purged
+	// once the database is usable.
+	var background sync.WaitGroup
+	prepareErr := make(chan error, 1)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		if mongoHealth != nil {
+			if err := prepareMongo(ctx, mongoHealth); err != nil {
+				if ctx.Err() == nil {
+					prepareErr <- err
+				}
+				return
+			}
+		}
+		if retention := cfg.Retention(); retention > 0 {
+			purgeTrash(ctx, stores, retention)
+		}
+	}()
 
-	fmt.Println("Mongo DB API")
-	r := router.Router()
+	server := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           r,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
 
-	fmt.Println("Server is getting started...")
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+	logger.Info("Server is running", "addr", cfg.Addr, "store", cfg.Store)
 
-	log.Fatal(http.ListenAndServe(":8080", r))
-	log.Println("Server is running on port 8080")
+	failed := false
+	select {
+	case err := <-serverErr:
+		logger.Error("Server failed", "err", err)
+		failed = true
+	case err := <-prepareErr:
+		logger.Error("Cannot prepare MongoDB", "err", err)
+		failed = true
+	case <-ctx.Done():
+		logger.Info("Shutting down, draining in-flight requests", "timeout", shutdownTimeout.String())
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("Error shutting down server", "err", err)
+	}
+	background.Wait()
+
+	if client != nil {
+		if err := client.Disconnect(shutdownCtx); err != nil {
+			logger.Error("Error disconnecting from MongoDB", "err", err)
+		}
+	}
+	logger.Info("Server stopped")
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// prepareMongo retries preparing MongoDB with exponential backoff until it
+// succeeds or ctx is done. Until then /readyz reports the server as
+// starting. Duplicate keys in stored data are returned at once, since no
+// retry can fix them.
+func prepareMongo(ctx context.Context, health *store.MongoHealth) error {
+	delay := retryInitial
+	for attempt := 1; ; attempt++ {
+		err := health.Prepare(ctx)
+		if err == nil {
+			slog.Info("MongoDB is ready", "attempts", attempt)
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var dupErr *store.DuplicateKeysError
+		if errors.As(err, &dupErr) {
+			return err
+		}
+		slog.Warn("MongoDB is not ready, retrying", "attempt", attempt, "retry_in", delay.String(), "err", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, retryMax)
+	}
+}
+
+// purgeTrash removes expired trash once at startup and then every hour until
+// ctx is done. Each purge that removes anything is audited with the system
+// as the actor.
+func purgeTrash(ctx context.Context, stores store.Stores, retention time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		contacts, questions, err := store.PurgeTrash(ctx, stores, retention)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("Error purging trash", "err", err)
+		} else if contacts+questions > 0 {
+			slog.Info("Purged trash", "contacts", contacts, "questions", questions)
+			auditPurge(ctx, stores.Audit, contacts, questions)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
 
+// auditPurge records a background purge in the audit log, in the same shape
+// as a purge requested through the API.
+func auditPurge(ctx context.Context, audit store.AuditStore, contacts, questions int64) {
+	err := audit.Append(context.WithoutCancel(ctx), model.AuditEntry{
+		Actor:      model.AuditSystemActor,
+		Action:     model.AuditPurged,
+		TargetType: model.AuditTrash,
+		At:         time.Now(),
+		After: model.Snapshot{
+			"contacts_purged":  contacts,
+			"questions_purged": questions,
+		},
+	})
+	if err != nil {
+		slog.Error("Error writing audit log", "err", err)
+	}
 }
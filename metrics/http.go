@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/httpstat"
+)
+
+// HTTP counts and times the requests served by one handler.
+type HTTP struct {
+	requests *CounterVec
+	duration *HistogramVec
+	inFlight *Gauge
+}
+
+// NewHTTP registers the HTTP request metrics with r.
+func NewHTTP(r *Registry) *HTTP {
+	return &HTTP{
+		requests: r.NewCounterVec("mongoapi_http_requests_total",
+			"HTTP requests served, by method, route template and status.",
+			"method", "route", "status"),
+		duration: r.NewHistogramVec("mongoapi_http_request_duration_seconds",
+			"Time taken to serve HTTP requests, by method, route template and status.",
+			DefaultBuckets, "method", "route", "status"),
+		inFlight: r.NewGauge("mongoapi_http_requests_in_flight",
+			"HTTP requests currently being served."),
+	}
+}
+
+// Middleware counts and times every request by the mux route template it
+// matched, so /contacts/1 and /contacts/2 share a series. Requests matching
+// no route are labelled "unmatched".
+func (m *HTTP) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		rec := httpstat.NewRecorder(w)
+		next.ServeHTTP(rec, r)
+
+		route := httpstat.Route(r)
+		status := strconv.Itoa(rec.Status())
+		m.requests.Inc(r.Method, route, status)
+		m.duration.Observe(time.Since(start).Seconds(), r.Method, route, status)
+	})
+}
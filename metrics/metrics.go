@@ -0,0 +1,351 @@
+// Package metrics keeps counters, gauges and histograms in memory and serves
+// them in the Prometheus text exposition format. Metrics are created on a
+// Registry, or on Default through the package-level constructors; creating
+// two with the same name in one registry panics.
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ContentType is the Prometheus text format version served by Handler.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets suit request and query latencies, in seconds.
+var DefaultBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// collector is one metric family.
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry holds metric families by name.
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{collectors: map[string]collector{}}
+}
+
+// Default holds process-wide metrics, created once by the package-level
+// constructors. Anything created per instance, such as per router, belongs
+// in a registry of its own so instances cannot replace each other's metrics.
+var Default = NewRegistry()
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.collectors[c.name()]; ok {
+		panic("metrics: " + c.name() + " is already registered")
+	}
+	r.collectors[c.name()] = c
+}
+
+// WriteTo writes every family, sorted by name.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	collectors := make([]collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.mu.Unlock()
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].name() < collectors[j].name() })
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// Handler serves the registry to Prometheus scrapers.
+func (r *Registry) Handler() http.Handler {
+	return Handler(r)
+}
+
+// Handler serves registries to Prometheus scrapers, one after another. Their
+// metric names must not overlap.
+func Handler(registries ...*Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		for _, r := range registries {
+			if _, err := r.WriteTo(w); err != nil {
+				return
+			}
+		}
+	})
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// labelSet joins label values into a map key.
+func labelSet(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// writeHeader writes the HELP and TYPE lines of a family.
+func writeHeader(w *bufio.Writer, name, help, kind string) {
+	w.WriteString("# HELP " + name + " " + strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help) + "\n")
+	w.WriteString("# TYPE " + name + " " + kind + "\n")
+}
+
+// writeSample writes one sample line. extra is an already formatted label
+// such as le="0.5", appended after the named labels.
+func writeSample(w *bufio.Writer, name string, names, values []string, extra string, value float64) {
+	w.WriteString(name)
+	if len(names) > 0 || extra != "" {
+		w.WriteByte('{')
+		for i, n := range names {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(n + `="` + escapeLabel(values[i]) + `"`)
+		}
+		if extra != "" {
+			if len(names) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extra)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, +1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sortedKeys returns the label sets of a family in a stable order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CounterVec counts events per label set.
+type CounterVec struct {
+	family string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64
+	sets   map[string][]string
+}
+
+// NewCounterVec creates a counter family and registers it with Default.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return Default.NewCounterVec(name, help, labels...)
+}
+
+// NewCounterVec is like the package-level NewCounterVec but registers with r.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{family: name, help: help, labels: labels, values: map[string]float64{}, sets: map[string][]string{}}
+	r.register(c)
+	return c
+}
+
+// Inc adds one to the counter for values, given in label order.
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds delta, which must not be negative, to the counter for values.
+func (c *CounterVec) Add(delta float64, values ...string) {
+	key := labelSet(values)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.sets[key]; !ok {
+		c.sets[key] = append([]string(nil), values...)
+	}
+	c.values[key] += delta
+}
+
+func (c *CounterVec) name() string { return c.family }
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	writeHeader(w, c.family, c.help, "counter")
+	for _, key := range sortedKeys(c.values) {
+		writeSample(w, c.family, c.labels, c.sets[key], "", c.values[key])
+	}
+}
+
+// Gauge is a single value that goes up and down.
+type Gauge struct {
+	family string
+	help   string
+	value  atomic.Int64
+}
+
+// NewGauge creates a gauge and registers it with Default.
+func NewGauge(name, help string) *Gauge {
+	return Default.NewGauge(name, help)
+}
+
+// NewGauge is like the package-level NewGauge but registers with r.
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	g := &Gauge{family: name, help: help}
+	r.register(g)
+	return g
+}
+
+func (g *Gauge) Inc() { g.value.Add(1) }
+func (g *Gauge) Dec() { g.value.Add(-1) }
+
+func (g *Gauge) name() string { return g.family }
+
+func (g *Gauge) write(w *bufio.Writer) {
+	writeHeader(w, g.family, g.help, "gauge")
+	writeSample(w, g.family, nil, nil, "", float64(g.value.Load()))
+}
+
+// Sample is one value reported by a GaugeFunc, with its label values.
+type Sample struct {
+	Values []string
+	Value  float64
+}
+
+// GaugeFunc reports values computed at scrape time.
+type GaugeFunc struct {
+	family  string
+	help    string
+	labels  []string
+	collect func() []Sample
+}
+
+// NewGaugeFunc creates a gauge family whose samples come from collect on
+// every scrape, and registers it with Default. A nil result reports nothing.
+func NewGaugeFunc(name, help string, labels []string, collect func() []Sample) *GaugeFunc {
+	return Default.NewGaugeFunc(name, help, labels, collect)
+}
+
+// NewGaugeFunc is like the package-level NewGaugeFunc but registers with r.
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, collect func() []Sample) *GaugeFunc {
+	g := &GaugeFunc{family: name, help: help, labels: labels, collect: collect}
+	r.register(g)
+	return g
+}
+
+func (g *GaugeFunc) name() string { return g.family }
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	samples := g.collect()
+	if samples == nil {
+		return
+	}
+	writeHeader(w, g.family, g.help, "gauge")
+	for _, s := range samples {
+		writeSample(w, g.family, g.labels, s.Values, "", s.Value)
+	}
+}
+
+// HistogramVec counts observations into cumulative buckets per label set.
+type HistogramVec struct {
+	family  string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogram
+}
+
+type histogram struct {
+	values []string
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec creates a histogram family and registers it with Default.
+// buckets are upper bounds in increasing order; +Inf is implied.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return Default.NewHistogramVec(name, help, buckets, labels...)
+}
+
+// NewHistogramVec is like the package-level NewHistogramVec but registers with
+// r.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{family: name, help: help, labels: labels, buckets: buckets, series: map[string]*histogram{}}
+	r.register(h)
+	return h
+}
+
+// Observe records v for values, given in label order.
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	key := labelSet(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{values: append([]string(nil), values...), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) name() string { return h.family }
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	writeHeader(w, h.family, h.help, "histogram")
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(w, h.family+"_bucket", h.labels, s.values, `le="`+formatFloat(bound)+`"`, float64(cumulative))
+		}
+		writeSample(w, h.family+"_bucket", h.labels, s.values, `le="+Inf"`, float64(s.count))
+		writeSample(w, h.family+"_sum", h.labels, s.values, "", s.sum)
+		writeSample(w, h.family+"_count", h.labels, s.values, "", float64(s.count))
+	}
+}
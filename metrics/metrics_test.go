@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"bytes"
+	"flag"
+	"math"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// golden compares got with testdata/name, or rewrites it with -update.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("exposition differs from %s\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func TestExposition(t *testing.T) {
+	r := NewRegistry()
+
+	requests := r.NewCounterVec("test_requests_total", "Requests served,\nby route\\method.", "method", "route")
+	requests.Inc("GET", "/a")
+	requests.Add(2.5, "GET", "/a")
+	requests.Inc("POST", `/b"quoted"\path`+"\n")
+
+	inFlight := r.NewGauge("test_in_flight", "Requests in flight.")
+	inFlight.Inc()
+	inFlight.Inc()
+	inFlight.Dec()
+
+	r.NewGaugeFunc("test_values", "Special float values.", []string{"kind"}, func() []Sample {
+		return []Sample{
+			{Values: []string{"inf"}, Value: math.Inf(1)},
+			{Values: []string{"neg_inf"}, Value: math.Inf(-1)},
+			{Values: []string{"nan"}, Value: math.NaN()},
+			{Values: []string{"small"}, Value: 0.000125},
+		}
+	})
+	r.NewGaugeFunc("test_failed", "Left out when collect returns nil.", nil, func() []Sample {
+		return nil
+	})
+
+	duration := r.NewHistogramVec("test_duration_seconds", "Request latency.", []float64{0.1, 0.5, 1}, "route")
+	for _, v := range []float64{0.05, 0.1, 0.3, 2} {
+		duration.Observe(v, "/a")
+	}
+	duration.Observe(0.5, "/b")
+
+	var buf bytes.Buffer
+	n, err := r.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo reported %d bytes, wrote %d", n, buf.Len())
+	}
+	golden(t, "exposition.golden", buf.Bytes())
+}
+
+func TestEmptyHistogramAndCounter(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_empty_total", "Never incremented.", "route")
+	r.NewHistogramVec("test_empty_seconds", "Never observed.", DefaultBuckets)
+
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "empty.golden", buf.Bytes())
+}
+
+func TestDuplicateNamePanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewGauge("test_gauge", "First.")
+	defer func() {
+		if recover() == nil {
+			t.Error("registering test_gauge twice did not panic")
+		}
+	}()
+	r.NewGaugeFunc("test_gauge", "Second.", nil, func() []Sample { return nil })
+}
+
+func TestHandlerServesRegistriesInOrder(t *testing.T) {
+	first, second := NewRegistry(), NewRegistry()
+	first.NewGauge("test_z", "Served first.")
+	second.NewGauge("test_a", "Served second.")
+
+	rec := httptest.NewRecorder()
+	Handler(first, second).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, ContentType)
+	}
+	want := "# HELP test_z Served first.\n# TYPE test_z gauge\ntest_z 0\n" +
+		"# HELP test_a Served second.\n# TYPE test_a gauge\ntest_a 0\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("body =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -1,23 +1,338 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Contact struct {
-	ID          int    `json:"id,omitempty" bson:"_id,omitempty"`
-	ContactName string `json:"contact_name,omitempty" bson:"contact_name,omitempty"`
-	Age         int    `json:"age,omitempty" bson:"age,omitempty"`
-	Mobile      string `json:"mobile,omitempty" bson:"mobile,omitempty"`
+	ID                int        `json:"id,omitempty" bson:"_id,omitempty"`
+	ContactName       string     `json:"contact_name,omitempty" bson:"contact_name,omitempty"`
+	Age               int        `json:"age" bson:"age,omitempty"`
+	Mobile            string     `json:"mobile,omitempty" bson:"mobile,omitempty"`
+	PreferredChannel  []Channel  `json:"preferred_channel,omitempty" bson:"preferred_channel,omitempty"`
+	PreferredLanguage []string   `json:"preferred_language,omitempty" bson:"preferred_language,omitempty"`
+	DeletedAt         *time.Time `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
 }
 
+// ContactPatch is a partial contact update. Nil fields are left unchanged;
+// send an empty list to clear channels or languages.
+type ContactPatch struct {
+	ContactName       *string    `json:"contact_name,omitempty"`
+	Age               *int       `json:"age,omitempty"`
+	Mobile            *string    `json:"mobile,omitempty"`
+	PreferredChannel  *[]Channel `json:"preferred_channel,omitempty"`
+	PreferredLanguage *[]string  `json:"preferred_language,omitempty"`
+}
+
+// Empty reports whether the patch changes nothing.
+func (p ContactPatch) Empty() bool {
+	return p.ContactName == nil && p.Age == nil && p.Mobile == nil &&
+		p.PreferredChannel == nil && p.PreferredLanguage == nil
+}
+
+// Apply writes the set fields of p onto c.
+func (p ContactPatch) Apply(c *Contact) {
+	if p.ContactName != nil {
+		c.ContactName = *p.ContactName
+	}
+	if p.Age != nil {
+		c.Age = *p.Age
+	}
+	if p.Mobile != nil {
+		c.Mobile = *p.Mobile
+	}
+	if p.PreferredChannel != nil {
+		c.PreferredChannel = append([]Channel{}, *p.PreferredChannel...)
+	}
+	if p.PreferredLanguage != nil {
+		c.PreferredLanguage = append([]string{}, *p.PreferredLanguage...)
+	}
+}
+
+// Known channel types
+const (
+	ChannelPhone    = "Phone"
+	ChannelEmail    = "Email"
+	ChannelWhatsApp = "WhatsApp"
+	ChannelSMS      = "SMS"
+)
+
+// Channel is one way to reach a contact. ID is its position in the
+// contact's PreferredChannel list.
+type Channel struct {
+	ID             int    `json:"id" bson:"id"`
+	ChannelName    string `json:"channel_name" bson:"channel_name"`
+	ChannelDetails string `json:"channel_details" bson:"channel_details"`
+}
+
+// Question types. Documents stored before types existed have no type and
+// are single choice.
+const (
+	QuestionSingleChoice   = "single_choice"
+	QuestionMultipleChoice = "multiple_choice"
+	QuestionTrueFalse      = "true_false"
+	QuestionNumeric        = "numeric"
+	QuestionShortText      = "short_text"
+)
+
+// Difficulty levels
+const (
+	DifficultyEasy   = "easy"
+	DifficultyMedium = "medium"
+	DifficultyHard   = "hard"
+)
+
+// Question is a quiz question. Which answer fields apply depends on Type:
+// CorrectAns for single_choice and true_false, CorrectAnswers for
+// multiple_choice, NumericAnswer and Tolerance for numeric, and
+// AcceptedAnswers or AnswerPattern for short_text.
 type Question struct {
-	ID           int       `json:"id" bson:"id,omitempty"`
-	Question     string    `json:"question" bson:"question"`
-	Options      []string  `json:"options" bson:"options"`
-	CorrectAns   string    `json:"correct_answer" bson:"correct_answer"`
-	Reason       string    `json:"reason" bson:"reason"`
-	Hidden       bool      `json:"hidden" bson:"hidden"`
+	ID              int        `json:"id" bson:"id,omitempty"`
+	Type            string     `json:"type" bson:"type,omitempty"`
+	Question        string     `json:"question" bson:"question"`
+	Options         []string   `json:"options" bson:"options"`
+	CorrectAns      string     `json:"correct_answer" bson:"correct_answer"`
+	CorrectAnswers  []string   `json:"correct_answers,omitempty" bson:"correct_answers,omitempty"`
+	NumericAnswer   *float64   `json:"numeric_answer,omitempty" bson:"numeric_answer,omitempty"`
+	Tolerance       float64    `json:"tolerance,omitempty" bson:"tolerance,omitempty"`
+	AcceptedAnswers []string   `json:"accepted_answers,omitempty" bson:"accepted_answers,omitempty"`
+	AnswerPattern   string     `json:"answer_pattern,omitempty" bson:"answer_pattern,omitempty"`
+	CaseSensitive   bool       `json:"case_sensitive,omitempty" bson:"case_sensitive,omitempty"`
+	Reason          string     `json:"reason" bson:"reason"`
+	Category        string     `json:"category,omitempty" bson:"category,omitempty"`
+	Tags            []string   `json:"tags,omitempty" bson:"tags,omitempty"`
+	Difficulty      string     `json:"difficulty,omitempty" bson:"difficulty,omitempty"`
+	Hidden          bool       `json:"hidden" bson:"hidden"`
+	CreatedAt       time.Time  `json:"created_at" bson:"created_at"`
+	LastModified    time.Time  `json:"last_modified" bson:"last_modified"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+}
+
+// Kind returns the question type, defaulting to single choice.
+func (q Question) Kind() string {
+	if q.Type == "" {
+		return QuestionSingleChoice
+	}
+	return q.Type
+}
+
+// MarshalJSON writes the question with its Kind as the type, so documents
+// stored before questions had types are not served with an empty one.
+func (q Question) MarshalJSON() ([]byte, error) {
+	type plain Question
+	q.Type = q.Kind()
+	return json.Marshal(plain(q))
+}
+
+// Revision actions
+const (
+	RevisionBaseline = "baseline"
+	RevisionCreated  = "created"
+	RevisionUpdated  = "updated"
+	RevisionHidden   = "hidden"
+	RevisionShown    = "shown"
+	RevisionReverted = "reverted"
+)
+
+// QuestionRevision is a snapshot of a question taken after each change.
+// Revisions are numbered from 1 per question. A baseline revision records a
+// question as it was before its first tracked change.
+type QuestionRevision struct {
+	QuestionID   int       `json:"question_id" bson:"question_id"`
+	Revision     int       `json:"revision" bson:"revision"`
+	Action       string    `json:"action" bson:"action"`
+	Author       string    `json:"author" bson:"author"`
+	RevertedFrom int       `json:"reverted_from,omitempty" bson:"reverted_from,omitempty"`
 	CreatedAt    time.Time `json:"created_at" bson:"created_at"`
-	LastModified time.Time `json:"last_modified" bson:"last_modified"`
+	Question     Question  `json:"question" bson:"question"`
+}
+
+// Audit actions. Question edits use the same words as their revisions.
+const (
+	AuditCreated   = RevisionCreated
+	AuditUpdated   = RevisionUpdated
+	AuditHidden    = RevisionHidden
+	AuditShown     = RevisionShown
+	AuditReverted  = RevisionReverted
+	AuditDeleted   = "deleted"
+	AuditRestored  = "restored"
+	AuditPurged    = "purged"
+	AuditDeleteAll = "deleted_all"
+)
+
+// AuditSystemActor is the actor of changes the server makes on its own, such
+// as purging expired trash.
+const AuditSystemActor = "system"
+
+// Audit target types
+const (
+	AuditContact  = "contact"
+	AuditQuestion = "question"
+	AuditTrash    = "trash"
+)
+
+// Snapshot is a record as it was on one side of an audited change, in its
+// JSON form.
+type Snapshot map[string]interface{}
+
+// AuditEntry records one change made through the API or, with
+// AuditSystemActor as the actor, by the server itself. Before is empty for
+// creations and After for removals; bulk actions have no target ID and
+// summarize their effect in After.
+type AuditEntry struct {
+	Actor      string    `json:"actor" bson:"actor"`
+	Action     string    `json:"action" bson:"action"`
+	Route      string    `json:"route" bson:"route"`
+	TargetType string    `json:"target_type" bson:"target_type"`
+	TargetID   int       `json:"target_id,omitempty" bson:"target_id,omitempty"`
+	At         time.Time `json:"at" bson:"at"`
+	Before     Snapshot  `json:"before,omitempty" bson:"before,omitempty"`
+	After      Snapshot  `json:"after,omitempty" bson:"after,omitempty"`
+}
+
+// FieldChange is one field that differs between two revisions.
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// RevisionDiff lists the fields changed from one revision to another.
+type RevisionDiff struct {
+	QuestionID int           `json:"question_id"`
+	From       int           `json:"from"`
+	To         int           `json:"to"`
+	Changes    []FieldChange `json:"changes"`
+}
+
+// TermCount is how many questions use a category or tag.
+type TermCount struct {
+	Name  string `json:"name" bson:"_id"`
+	Count int64  `json:"count" bson:"count"`
+}
+
+// FieldError describes why one field of a request was rejected. Field uses
+// the JSON name, with an index for list elements such as "options[1]".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ConfirmToken is handed out when a destructive request needs confirming.
+// Repeating the request with the token before it expires carries it out.
+type ConfirmToken struct {
+	Token     string    `json:"confirm_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ImportReport summarizes a bulk contact import. With DryRun set nothing was
+// written and Imported counts the rows that would have been.
+type ImportReport struct {
+	Format   string           `json:"format"`
+	DryRun   bool             `json:"dry_run"`
+	Total    int              `json:"total"`
+	Imported int              `json:"imported"`
+	Failed   int              `json:"failed"`
+	IDs      []int            `json:"ids,omitempty"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Mobile  string `json:"mobile,omitempty"`
+	Message string `json:"message"`
+}
+
+// Page is one page of a paginated listing.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	Page       int    `json:"page,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// QuizQuestion is a question as shown to quiz takers, without its answer key.
+type QuizQuestion struct {
+	ID       int      `json:"id"`
+	Type     string   `json:"type"`
+	Question string   `json:"question"`
+	Options  []string `json:"options,omitempty"`
+}
+
+// QuizSubmission is a set of answers sent in by a quiz taker.
+type QuizSubmission struct {
+	Answers []QuizAnswer `json:"answers"`
+}
+
+// QuizAnswer is the answer to one question. Multiple choice questions are
+// answered with Answers, every other type with Answer.
+type QuizAnswer struct {
+	QuestionID int      `json:"question_id"`
+	Answer     string   `json:"answer"`
+	Answers    []string `json:"answers,omitempty"`
+}
+
+// QuizResult is a graded submission. The answer key is only revealed here.
+type QuizResult struct {
+	Score   int                `json:"score" bson:"score"`
+	Total   int                `json:"total" bson:"total"`
+	Results []QuizAnswerResult `json:"results" bson:"results"`
+}
+
+type QuizAnswerResult struct {
+	QuestionID     int      `json:"question_id" bson:"question_id"`
+	Answer         string   `json:"answer" bson:"answer"`
+	Answers        []string `json:"answers,omitempty" bson:"answers,omitempty"`
+	Correct        bool     `json:"correct" bson:"correct"`
+	CorrectAns     string   `json:"correct_answer" bson:"correct_answer"`
+	CorrectAnswers []string `json:"correct_answers,omitempty" bson:"correct_answers,omitempty"`
+	Reason         string   `json:"reason" bson:"reason"`
+}
+
+// Attempt statuses
+const (
+	AttemptInProgress = "in_progress"
+	AttemptSubmitted  = "submitted"
+)
+
+// Attempt is one run through a quiz. Snapshot freezes the selected questions,
+// options already shuffled, so later edits do not change how it is graded.
+type Attempt struct {
+	ID              int            `json:"id" bson:"id"`
+	UserID          string         `json:"user_id" bson:"user_id"`
+	Status          string         `json:"status" bson:"status"`
+	Snapshot        []Question     `json:"-" bson:"snapshot"`
+	Questions       []QuizQuestion `json:"questions,omitempty" bson:"-"`
+	StartedAt       time.Time      `json:"started_at" bson:"started_at"`
+	SubmittedAt     *time.Time     `json:"submitted_at,omitempty" bson:"submitted_at,omitempty"`
+	DurationSeconds float64        `json:"duration_seconds,omitempty" bson:"duration_seconds,omitempty"`
+	Result          *QuizResult    `json:"result,omitempty" bson:"result,omitempty"`
+}
+
+// Health states reported by the health endpoints. A degraded service is
+// still ready but answering slowly.
+const (
+	HealthOK          = "ok"
+	HealthDegraded    = "degraded"
+	HealthStarting    = "starting"
+	HealthUnavailable = "unavailable"
+)
+
+// HealthCheck is the outcome of one dependency check.
+type HealthCheck struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// HealthReport is returned by /healthz and /readyz.
+type HealthReport struct {
+	Status        string        `json:"status"`
+	UptimeSeconds float64       `json:"uptime_seconds"`
+	Checks        []HealthCheck `json:"checks,omitempty"`
 }
 
 type Response struct {
@@ -0,0 +1,166 @@
+// Package openapi serves the OpenAPI 3 description of the API and a page to
+// browse it. The document is maintained by hand in openapi.yaml next to this
+// file; Check compares it with the routes a router actually registers.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+//go:embed docs.html
+var docsHTML []byte
+
+// methods are the path item keys that describe operations.
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+var (
+	loadOnce sync.Once
+	spec     map[string]interface{}
+	specJSON []byte
+	loadErr  error
+)
+
+// load parses the embedded document once.
+func load() (map[string]interface{}, []byte, error) {
+	loadOnce.Do(func() {
+		var doc interface{}
+		if loadErr = yaml.Unmarshal(specYAML, &doc); loadErr != nil {
+			loadErr = fmt.Errorf("openapi: %w", loadErr)
+			return
+		}
+		var ok bool
+		if spec, ok = stringKeys(doc).(map[string]interface{}); !ok {
+			loadErr = fmt.Errorf("openapi: document is not a mapping")
+			return
+		}
+		specJSON, loadErr = json.Marshal(spec)
+	})
+	return spec, specJSON, loadErr
+}
+
+// stringKeys converts YAML mappings with non-string keys, such as unquoted
+// status codes, to the string-keyed maps encoding/json can marshal.
+func stringKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = stringKeys(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = stringKeys(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = stringKeys(item)
+		}
+		return v
+	}
+	return v
+}
+
+// JSON returns the document in its JSON form.
+func JSON() ([]byte, error) {
+	_, raw, err := load()
+	return raw, err
+}
+
+// Handler serves the document as JSON.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, err := JSON()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(raw)
+	})
+}
+
+// DocsHandler serves a self-contained page that renders /openapi.json and
+// can send requests to the API from the browser.
+func DocsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(docsHTML)
+	})
+}
+
+// muxVariable matches a path variable with an optional pattern, such as
+// {rev:[0-9]+}.
+var muxVariable = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// Check lists the differences between the operations in the document and
+// the routes registered on router, one "METHOD /path: problem" line each,
+// sorted. It returns nil when they match.
+func Check(router *mux.Router) ([]string, error) {
+	doc, _, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	documented := map[string]bool{}
+	paths, _ := doc["paths"].(map[string]interface{})
+	for path, item := range paths {
+		ops, _ := item.(map[string]interface{})
+		for _, method := range methods {
+			if _, ok := ops[method]; ok {
+				documented[strings.ToUpper(method)+" "+path] = true
+			}
+		}
+	}
+
+	routed := map[string]bool{}
+	err = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if route.GetHandler() == nil {
+			return nil
+		}
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		routeMethods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		path := muxVariable.ReplaceAllString(tpl, "{$1}")
+		for _, method := range routeMethods {
+			routed[method+" "+path] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for op := range routed {
+		if !documented[op] {
+			problems = append(problems, op+": routed but missing from the OpenAPI document")
+		}
+	}
+	for op := range documented {
+		if !routed[op] {
+			problems = append(problems, op+": documented but not routed")
+		}
+	}
+	sort.Strings(problems)
+	return problems, nil
+}
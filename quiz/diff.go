@@ -0,0 +1,45 @@
+package quiz
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/AniketGodambe/mongoapi/model"
+)
+
+// Fields that identify or timestamp a question rather than describe it.
+var diffIgnored = map[string]bool{"id": true, "created_at": true, "last_modified": true}
+
+// Diff lists the fields that differ between two versions of a question,
+// named and valued as in the API's JSON and sorted by field name.
+func Diff(from, to model.Question) []model.FieldChange {
+	a, b := fieldMap(from), fieldMap(to)
+
+	names := map[string]bool{}
+	for name := range a {
+		names[name] = true
+	}
+	for name := range b {
+		names[name] = true
+	}
+
+	changes := []model.FieldChange{}
+	for name := range names {
+		if diffIgnored[name] || reflect.DeepEqual(a[name], b[name]) {
+			continue
+		}
+		changes = append(changes, model.FieldChange{Field: name, From: a[name], To: b[name]})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// fieldMap decodes q's JSON form into a map so any two versions compare
+// field by field, including fields added later.
+func fieldMap(q model.Question) map[string]interface{} {
+	data, _ := json.Marshal(q)
+	fields := map[string]interface{}{}
+	json.Unmarshal(data, &fields)
+	return fields
+}
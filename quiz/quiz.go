@@ -0,0 +1,125 @@
+// Package quiz turns stored questions into something safe to show learners
+// and grades their answers.
+package quiz
+
+import (
+	"math"
+	"math/rand/v2"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/AniketGodambe/mongoapi/model"
+)
+
+// Public strips the answer key from q.
+func Public(q model.Question) model.QuizQuestion {
+	return model.QuizQuestion{
+		ID:       q.ID,
+		Type:     q.Kind(),
+		Question: q.Question,
+		Options:  q.Options,
+	}
+}
+
+// Grade reports whether answer is correct for q. Surrounding whitespace is
+// ignored for every type.
+func Grade(q model.Question, answer model.QuizAnswer) bool {
+	given := strings.TrimSpace(answer.Answer)
+
+	switch q.Kind() {
+	case model.QuestionMultipleChoice:
+		// Every correct option and nothing else, in any order
+		picked := make([]string, 0, len(answer.Answers))
+		for _, a := range answer.Answers {
+			picked = append(picked, strings.TrimSpace(a))
+		}
+		slices.Sort(picked)
+		want := slices.Clone(q.CorrectAnswers)
+		slices.Sort(want)
+		return len(want) > 0 && slices.Equal(slices.Compact(picked), want)
+	case model.QuestionTrueFalse:
+		return strings.EqualFold(given, q.CorrectAns)
+	case model.QuestionNumeric:
+		value, err := strconv.ParseFloat(given, 64)
+		if err != nil || q.NumericAnswer == nil {
+			return false
+		}
+		return math.Abs(value-*q.NumericAnswer) <= q.Tolerance
+	case model.QuestionShortText:
+		normalized := normalizeText(given, q.CaseSensitive)
+		for _, accepted := range q.AcceptedAnswers {
+			if normalizeText(accepted, q.CaseSensitive) == normalized {
+				return true
+			}
+		}
+		if q.AnswerPattern != "" {
+			re, err := answerPattern(q)
+			return err == nil && re.MatchString(normalizeText(given, true))
+		}
+		return false
+	default:
+		return given == strings.TrimSpace(q.CorrectAns)
+	}
+}
+
+// Result grades answer and reveals the answer key for q.
+func Result(q model.Question, answer model.QuizAnswer) model.QuizAnswerResult {
+	result := model.QuizAnswerResult{
+		QuestionID: q.ID,
+		Answer:     answer.Answer,
+		Answers:    answer.Answers,
+		Correct:    Grade(q, answer),
+		CorrectAns: q.CorrectAns,
+		Reason:     q.Reason,
+	}
+
+	switch q.Kind() {
+	case model.QuestionMultipleChoice:
+		result.CorrectAnswers = q.CorrectAnswers
+	case model.QuestionNumeric:
+		if q.NumericAnswer != nil {
+			result.CorrectAns = strconv.FormatFloat(*q.NumericAnswer, 'f', -1, 64)
+		}
+	case model.QuestionShortText:
+		result.CorrectAnswers = q.AcceptedAnswers
+		if len(q.AcceptedAnswers) > 0 {
+			result.CorrectAns = q.AcceptedAnswers[0]
+		}
+	}
+	return result
+}
+
+// Shuffle returns a copy of q with its options in random order. True/false
+// options keep their order.
+func Shuffle(q model.Question) model.Question {
+	if q.Kind() == model.QuestionTrueFalse {
+		return q
+	}
+	options := append([]string(nil), q.Options...)
+	rand.Shuffle(len(options), func(i, j int) {
+		options[i], options[j] = options[j], options[i]
+	})
+	q.Options = options
+	return q
+}
+
+// normalizeText folds runs of whitespace and, unless caseSensitive, case.
+func normalizeText(s string, caseSensitive bool) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if !caseSensitive {
+		s = strings.ToLower(s)
+	}
+	return s
+}
+
+// answerPattern compiles the short text pattern of q so it must match the
+// whole answer.
+func answerPattern(q model.Question) (*regexp.Regexp, error) {
+	flags := "(?i)"
+	if q.CaseSensitive {
+		flags = ""
+	}
+	return regexp.Compile(flags + "^(?:" + q.AnswerPattern + ")$")
+}
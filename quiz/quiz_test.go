@@ -0,0 +1,129 @@
+package quiz
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/AniketGodambe/mongoapi/model"
+)
+
+func TestGrade(t *testing.T) {
+	single := model.Question{Question: "2+2?", Options: []string{"3", "4"}, CorrectAns: "4"}
+	multiple := model.Question{Type: model.QuestionMultipleChoice, Options: []string{"2", "3", "4"}, CorrectAnswers: []string{"3", "2"}}
+	trueFalse := model.Question{Type: model.QuestionTrueFalse, CorrectAns: "true"}
+	numeric := model.Question{Type: model.QuestionNumeric, NumericAnswer: float(2.5), Tolerance: 0.25}
+	exact := model.Question{Type: model.QuestionNumeric, NumericAnswer: float(42)}
+	text := model.Question{Type: model.QuestionShortText, AcceptedAnswers: []string{"New  Delhi", "Delhi"}}
+	caseSensitive := model.Question{Type: model.QuestionShortText, AcceptedAnswers: []string{"NaCl"}, CaseSensitive: true}
+	pattern := model.Question{Type: model.QuestionShortText, AnswerPattern: `colou?r`}
+
+	tests := []struct {
+		name     string
+		question model.Question
+		answer   model.QuizAnswer
+		want     bool
+	}{
+		{"single correct", single, model.QuizAnswer{Answer: " 4 "}, true},
+		{"single wrong", single, model.QuizAnswer{Answer: "3"}, false},
+		{"single is case sensitive", model.Question{CorrectAns: "Paris"}, model.QuizAnswer{Answer: "paris"}, false},
+		{"multiple in any order", multiple, model.QuizAnswer{Answers: []string{"2", " 3"}}, true},
+		{"multiple repeated pick", multiple, model.QuizAnswer{Answers: []string{"3", "2", "3"}}, true},
+		{"multiple missing one", multiple, model.QuizAnswer{Answers: []string{"2"}}, false},
+		{"multiple with extra", multiple, model.QuizAnswer{Answers: []string{"2", "3", "4"}}, false},
+		{"multiple ignores answer", multiple, model.QuizAnswer{Answer: "2"}, false},
+		{"multiple without key", model.Question{Type: model.QuestionMultipleChoice}, model.QuizAnswer{}, false},
+		{"true false any case", trueFalse, model.QuizAnswer{Answer: "TRUE"}, true},
+		{"true false wrong", trueFalse, model.QuizAnswer{Answer: "false"}, false},
+		{"numeric within tolerance", numeric, model.QuizAnswer{Answer: "2.6"}, true},
+		{"numeric on the edge", numeric, model.QuizAnswer{Answer: "2.25"}, true},
+		{"numeric outside tolerance", numeric, model.QuizAnswer{Answer: "2.8"}, false},
+		{"numeric exact", exact, model.QuizAnswer{Answer: "42.0"}, true},
+		{"numeric not a number", exact, model.QuizAnswer{Answer: "forty two"}, false},
+		{"numeric without key", model.Question{Type: model.QuestionNumeric}, model.QuizAnswer{Answer: "0"}, false},
+		{"text folds case and spaces", text, model.QuizAnswer{Answer: " new   delhi "}, true},
+		{"text second answer", text, model.QuizAnswer{Answer: "DELHI"}, true},
+		{"text wrong", text, model.QuizAnswer{Answer: "Mumbai"}, false},
+		{"text case sensitive", caseSensitive, model.QuizAnswer{Answer: "NaCl"}, true},
+		{"text case sensitive wrong case", caseSensitive, model.QuizAnswer{Answer: "nacl"}, false},
+		{"pattern", pattern, model.QuizAnswer{Answer: "Colour"}, true},
+		{"pattern matches whole answer", pattern, model.QuizAnswer{Answer: "colors"}, false},
+		{"unanswered", single, model.QuizAnswer{}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Grade(tc.question, tc.answer); got != tc.want {
+				t.Errorf("Grade = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResultRevealsAnswerKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		question model.Question
+		answer   model.QuizAnswer
+		want     model.QuizAnswerResult
+	}{
+		{
+			name:     "single choice",
+			question: model.Question{ID: 1, Options: []string{"3", "4"}, CorrectAns: "4", Reason: "Arithmetic"},
+			answer:   model.QuizAnswer{QuestionID: 1, Answer: "3"},
+			want:     model.QuizAnswerResult{QuestionID: 1, Answer: "3", CorrectAns: "4", Reason: "Arithmetic"},
+		},
+		{
+			name:     "multiple choice",
+			question: model.Question{ID: 2, Type: model.QuestionMultipleChoice, CorrectAnswers: []string{"2", "3"}},
+			answer:   model.QuizAnswer{QuestionID: 2, Answers: []string{"3", "2"}},
+			want:     model.QuizAnswerResult{QuestionID: 2, Answers: []string{"3", "2"}, Correct: true, CorrectAnswers: []string{"2", "3"}},
+		},
+		{
+			name:     "numeric",
+			question: model.Question{ID: 3, Type: model.QuestionNumeric, NumericAnswer: float(2.5)},
+			answer:   model.QuizAnswer{QuestionID: 3, Answer: "2.5"},
+			want:     model.QuizAnswerResult{QuestionID: 3, Answer: "2.5", Correct: true, CorrectAns: "2.5"},
+		},
+		{
+			name:     "short text",
+			question: model.Question{ID: 4, Type: model.QuestionShortText, AcceptedAnswers: []string{"Delhi", "New Delhi"}},
+			answer:   model.QuizAnswer{QuestionID: 4, Answer: "Mumbai"},
+			want:     model.QuizAnswerResult{QuestionID: 4, Answer: "Mumbai", CorrectAns: "Delhi", CorrectAnswers: []string{"Delhi", "New Delhi"}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Result(tc.question, tc.answer); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Result =\n%+v\nwant\n%+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPublicHidesAnswerKey(t *testing.T) {
+	q := model.Question{ID: 7, Question: "2+2?", Options: []string{"3", "4"}, CorrectAns: "4", Reason: "Arithmetic"}
+	want := model.QuizQuestion{ID: 7, Type: model.QuestionSingleChoice, Question: "2+2?", Options: []string{"3", "4"}}
+	if got := Public(q); !reflect.DeepEqual(got, want) {
+		t.Errorf("Public = %+v, want %+v", got, want)
+	}
+}
+
+func TestShuffle(t *testing.T) {
+	q := model.Question{Options: []string{"a", "b", "c", "d", "e", "f"}}
+	shuffled := Shuffle(q)
+	if !reflect.DeepEqual(q.Options, []string{"a", "b", "c", "d", "e", "f"}) {
+		t.Fatalf("Shuffle changed the original options to %q", q.Options)
+	}
+	got := slices.Clone(shuffled.Options)
+	slices.Sort(got)
+	if !reflect.DeepEqual(got, q.Options) {
+		t.Fatalf("Shuffle returned options %q, not a permutation of %q", shuffled.Options, q.Options)
+	}
+
+	tf := model.Question{Type: model.QuestionTrueFalse, Options: []string{"true", "false"}}
+	for range 20 {
+		if got := Shuffle(tf).Options; !reflect.DeepEqual(got, tf.Options) {
+			t.Fatalf("Shuffle reordered true/false options to %q", got)
+		}
+	}
+}
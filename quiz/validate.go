@@ -0,0 +1,235 @@
+package quiz
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AniketGodambe/mongoapi/model"
+)
+
+// Limits enforced by Validate.
+const (
+	MinOptions        = 2
+	MaxOptions        = 6
+	MaxQuestionLength = 500
+	MaxOptionLength   = 200
+	MaxReasonLength   = 1000
+	MaxPatternLength  = 200
+	MaxAccepted       = 20
+	MaxCategoryLength = 64
+	MaxTags           = 10
+	MaxTagLength      = 32
+)
+
+// Difficulties lists the accepted difficulty levels, easiest first.
+var Difficulties = []string{model.DifficultyEasy, model.DifficultyMedium, model.DifficultyHard}
+
+// trueFalseOptions are the options of every true/false question.
+var trueFalseOptions = []string{"true", "false"}
+
+// fieldErrors collects the problems found in one question.
+type fieldErrors []model.FieldError
+
+func (errs *fieldErrors) add(field, format string, args ...any) {
+	*errs = append(*errs, model.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate trims the text fields of q in place and reports every problem
+// that would make it unfit to show to learners. A missing type is set to
+// single choice. A nil result means q is valid.
+func Validate(q *model.Question) []model.FieldError {
+	var errs fieldErrors
+
+	q.Type = q.Kind()
+	q.Question = strings.TrimSpace(q.Question)
+	q.CorrectAns = strings.TrimSpace(q.CorrectAns)
+	q.Reason = strings.TrimSpace(q.Reason)
+
+	if q.Question == "" {
+		errs.add("question", "is required")
+	} else if n := utf8.RuneCountInString(q.Question); n > MaxQuestionLength {
+		errs.add("question", "must be at most %d characters, got %d", MaxQuestionLength, n)
+	}
+
+	switch q.Type {
+	case model.QuestionSingleChoice:
+		validateOptions(q, &errs)
+		validateChoice(q, &errs)
+	case model.QuestionMultipleChoice:
+		validateOptions(q, &errs)
+		validateChoices(q, &errs)
+	case model.QuestionTrueFalse:
+		validateTrueFalse(q, &errs)
+	case model.QuestionNumeric:
+		validateNoOptions(q, &errs)
+		validateNumeric(q, &errs)
+	case model.QuestionShortText:
+		validateNoOptions(q, &errs)
+		validateShortText(q, &errs)
+	default:
+		errs.add("type", "must be one of %s, %s, %s, %s or %s",
+			model.QuestionSingleChoice, model.QuestionMultipleChoice, model.QuestionTrueFalse,
+			model.QuestionNumeric, model.QuestionShortText)
+	}
+
+	if n := utf8.RuneCountInString(q.Reason); n > MaxReasonLength {
+		errs.add("reason", "must be at most %d characters, got %d", MaxReasonLength, n)
+	}
+	validateClassification(q, &errs)
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func validateOptions(q *model.Question, errs *fieldErrors) {
+	if n := len(q.Options); n < MinOptions || n > MaxOptions {
+		errs.add("options", "must have between %d and %d options, got %d", MinOptions, MaxOptions, n)
+	}
+	seen := make(map[string]int, len(q.Options))
+	for i := range q.Options {
+		field := fmt.Sprintf("options[%d]", i)
+		q.Options[i] = strings.TrimSpace(q.Options[i])
+		option := q.Options[i]
+		if option == "" {
+			errs.add(field, "must not be empty")
+			continue
+		}
+		if n := utf8.RuneCountInString(option); n > MaxOptionLength {
+			errs.add(field, "must be at most %d characters, got %d", MaxOptionLength, n)
+		}
+		key := strings.ToLower(option)
+		if j, ok := seen[key]; ok {
+			errs.add(field, "duplicates options[%d]", j)
+			continue
+		}
+		seen[key] = i
+	}
+}
+
+func validateChoice(q *model.Question, errs *fieldErrors) {
+	if q.CorrectAns == "" {
+		errs.add("correct_answer", "is required")
+	} else if !slices.Contains(q.Options, q.CorrectAns) {
+		errs.add("correct_answer", "must be one of the options")
+	}
+}
+
+func validateChoices(q *model.Question, errs *fieldErrors) {
+	if len(q.CorrectAnswers) == 0 {
+		errs.add("correct_answers", "must list at least one option")
+		return
+	}
+	for i := range q.CorrectAnswers {
+		field := fmt.Sprintf("correct_answers[%d]", i)
+		q.CorrectAnswers[i] = strings.TrimSpace(q.CorrectAnswers[i])
+		answer := q.CorrectAnswers[i]
+		if !slices.Contains(q.Options, answer) {
+			errs.add(field, "must be one of the options")
+		} else if j := slices.Index(q.CorrectAnswers, answer); j < i {
+			errs.add(field, "duplicates correct_answers[%d]", j)
+		}
+	}
+}
+
+func validateTrueFalse(q *model.Question, errs *fieldErrors) {
+	if len(q.Options) > 0 {
+		given := make([]string, len(q.Options))
+		for i, option := range q.Options {
+			given[i] = strings.ToLower(strings.TrimSpace(option))
+		}
+		if !slices.Equal(given, trueFalseOptions) {
+			errs.add("options", "must be omitted or exactly [\"true\", \"false\"]")
+		}
+	}
+	q.Options = slices.Clone(trueFalseOptions)
+
+	q.CorrectAns = strings.ToLower(q.CorrectAns)
+	if !slices.Contains(trueFalseOptions, q.CorrectAns) {
+		errs.add("correct_answer", "must be \"true\" or \"false\"")
+	}
+}
+
+func validateNoOptions(q *model.Question, errs *fieldErrors) {
+	if len(q.Options) > 0 {
+		errs.add("options", "must be omitted for %s questions", q.Type)
+	}
+	q.Options = nil
+}
+
+func validateNumeric(q *model.Question, errs *fieldErrors) {
+	if q.NumericAnswer == nil {
+		errs.add("numeric_answer", "is required")
+	} else if math.IsNaN(*q.NumericAnswer) || math.IsInf(*q.NumericAnswer, 0) {
+		errs.add("numeric_answer", "must be a finite number")
+	}
+	if q.Tolerance < 0 || math.IsNaN(q.Tolerance) || math.IsInf(q.Tolerance, 0) {
+		errs.add("tolerance", "must be a finite number of at least 0")
+	}
+}
+
+func validateShortText(q *model.Question, errs *fieldErrors) {
+	q.AnswerPattern = strings.TrimSpace(q.AnswerPattern)
+	if len(q.AcceptedAnswers) == 0 && q.AnswerPattern == "" {
+		errs.add("accepted_answers", "must list at least one answer unless answer_pattern is set")
+	}
+	if len(q.AcceptedAnswers) > MaxAccepted {
+		errs.add("accepted_answers", "must have at most %d answers, got %d", MaxAccepted, len(q.AcceptedAnswers))
+	}
+	for i := range q.AcceptedAnswers {
+		field := fmt.Sprintf("accepted_answers[%d]", i)
+		q.AcceptedAnswers[i] = strings.TrimSpace(q.AcceptedAnswers[i])
+		answer := q.AcceptedAnswers[i]
+		if answer == "" {
+			errs.add(field, "must not be empty")
+		} else if n := utf8.RuneCountInString(answer); n > MaxOptionLength {
+			errs.add(field, "must be at most %d characters, got %d", MaxOptionLength, n)
+		}
+	}
+	if q.AnswerPattern != "" {
+		if n := utf8.RuneCountInString(q.AnswerPattern); n > MaxPatternLength {
+			errs.add("answer_pattern", "must be at most %d characters, got %d", MaxPatternLength, n)
+		} else if _, err := answerPattern(*q); err != nil {
+			errs.add("answer_pattern", "is not a valid regular expression: %v", err)
+		}
+	}
+}
+
+// NormalizeTag trims and lower-cases a tag so filters match regardless of
+// how it was typed.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+func validateClassification(q *model.Question, errs *fieldErrors) {
+	q.Category = strings.TrimSpace(q.Category)
+	if n := utf8.RuneCountInString(q.Category); n > MaxCategoryLength {
+		errs.add("category", "must be at most %d characters, got %d", MaxCategoryLength, n)
+	}
+
+	q.Difficulty = strings.ToLower(strings.TrimSpace(q.Difficulty))
+	if q.Difficulty != "" && !slices.Contains(Difficulties, q.Difficulty) {
+		errs.add("difficulty", "must be one of %s", strings.Join(Difficulties, ", "))
+	}
+
+	var tags []string
+	for i, tag := range q.Tags {
+		field := fmt.Sprintf("tags[%d]", i)
+		tag = NormalizeTag(tag)
+		if tag == "" {
+			errs.add(field, "must not be empty")
+		} else if n := utf8.RuneCountInString(tag); n > MaxTagLength {
+			errs.add(field, "must be at most %d characters, got %d", MaxTagLength, n)
+		} else if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > MaxTags {
+		errs.add("tags", "must have at most %d tags, got %d", MaxTags, len(tags))
+	}
+	q.Tags = tags
+}
@@ -0,0 +1,177 @@
+package quiz
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AniketGodambe/mongoapi/model"
+)
+
+func float(v float64) *float64 { return &v }
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		question model.Question
+		// want lists "field: message" for every expected error; nil means valid
+		want []string
+	}{
+		{
+			name:     "single choice",
+			question: model.Question{Question: "2+2?", Options: []string{"3", "4"}, CorrectAns: "4"},
+		},
+		{
+			name:     "missing text and answer",
+			question: model.Question{Options: []string{"a", "b"}},
+			want:     []string{"question: is required", "correct_answer: is required"},
+		},
+		{
+			name:     "too long",
+			question: model.Question{Question: strings.Repeat("é", MaxQuestionLength+1), Options: []string{"a", "b"}, CorrectAns: "a"},
+			want:     []string{fmt.Sprintf("question: must be at most %d characters, got %d", MaxQuestionLength, MaxQuestionLength+1)},
+		},
+		{
+			name:     "answer not an option",
+			question: model.Question{Question: "2+2?", Options: []string{"3", "4"}, CorrectAns: "5"},
+			want:     []string{"correct_answer: must be one of the options"},
+		},
+		{
+			name:     "too few options",
+			question: model.Question{Question: "2+2?", Options: []string{"4"}, CorrectAns: "4"},
+			want:     []string{"options: must have between 2 and 6 options, got 1"},
+		},
+		{
+			name:     "empty and duplicate options",
+			question: model.Question{Question: "2+2?", Options: []string{"4", " ", "FOUR", "four"}, CorrectAns: "4"},
+			want:     []string{"options[1]: must not be empty", "options[3]: duplicates options[2]"},
+		},
+		{
+			name:     "multiple choice",
+			question: model.Question{Type: model.QuestionMultipleChoice, Question: "Primes?", Options: []string{"2", "3", "4"}, CorrectAnswers: []string{"2", "3"}},
+		},
+		{
+			name:     "multiple choice without answers",
+			question: model.Question{Type: model.QuestionMultipleChoice, Question: "Primes?", Options: []string{"2", "3"}},
+			want:     []string{"correct_answers: must list at least one option"},
+		},
+		{
+			name:     "multiple choice bad answers",
+			question: model.Question{Type: model.QuestionMultipleChoice, Question: "Primes?", Options: []string{"2", "3"}, CorrectAnswers: []string{"2", "5", "2"}},
+			want:     []string{"correct_answers[1]: must be one of the options", "correct_answers[2]: duplicates correct_answers[0]"},
+		},
+		{
+			name:     "true false",
+			question: model.Question{Type: model.QuestionTrueFalse, Question: "Sky is blue?", CorrectAns: "TRUE"},
+		},
+		{
+			name:     "true false wrong options",
+			question: model.Question{Type: model.QuestionTrueFalse, Question: "Sky is blue?", Options: []string{"yes", "no"}, CorrectAns: "yes"},
+			want:     []string{`options: must be omitted or exactly ["true", "false"]`, `correct_answer: must be "true" or "false"`},
+		},
+		{
+			name:     "numeric",
+			question: model.Question{Type: model.QuestionNumeric, Question: "Pi?", NumericAnswer: float(3.14), Tolerance: 0.01},
+		},
+		{
+			name:     "numeric missing answer with options",
+			question: model.Question{Type: model.QuestionNumeric, Question: "Pi?", Options: []string{"3"}, Tolerance: -1},
+			want: []string{
+				"options: must be omitted for numeric questions",
+				"numeric_answer: is required",
+				"tolerance: must be a finite number of at least 0",
+			},
+		},
+		{
+			name:     "numeric not finite",
+			question: model.Question{Type: model.QuestionNumeric, Question: "Pi?", NumericAnswer: float(math.Inf(1))},
+			want:     []string{"numeric_answer: must be a finite number"},
+		},
+		{
+			name:     "short text pattern only",
+			question: model.Question{Type: model.QuestionShortText, Question: "Capital of France?", AnswerPattern: "paris( city)?"},
+		},
+		{
+			name:     "short text without answers",
+			question: model.Question{Type: model.QuestionShortText, Question: "Capital of France?"},
+			want:     []string{"accepted_answers: must list at least one answer unless answer_pattern is set"},
+		},
+		{
+			name:     "short text bad pattern and empty answer",
+			question: model.Question{Type: model.QuestionShortText, Question: "Capital of France?", AcceptedAnswers: []string{" "}, AnswerPattern: "(paris"},
+			want: []string{
+				"accepted_answers[0]: must not be empty",
+				"answer_pattern: is not a valid regular expression: error parsing regexp: missing closing ): `(?i)^(?:(paris)$`",
+			},
+		},
+		{
+			name:     "unknown type",
+			question: model.Question{Type: "essay", Question: "Why?"},
+			want:     []string{"type: must be one of single_choice, multiple_choice, true_false, numeric or short_text"},
+		},
+		{
+			name: "classification",
+			question: model.Question{Question: "2+2?", Options: []string{"3", "4"}, CorrectAns: "4",
+				Category: strings.Repeat("c", MaxCategoryLength+1), Difficulty: "impossible", Tags: []string{"ok", ""}},
+			want: []string{
+				fmt.Sprintf("category: must be at most %d characters, got %d", MaxCategoryLength, MaxCategoryLength+1),
+				"difficulty: must be one of easy, medium, hard",
+				"tags[1]: must not be empty",
+			},
+		},
+		{
+			name: "too many tags",
+			question: model.Question{Question: "2+2?", Options: []string{"3", "4"}, CorrectAns: "4",
+				Tags: strings.Split("a,b,c,d,e,f,g,h,i,j,k", ",")},
+			want: []string{"tags: must have at most 10 tags, got 11"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, e := range Validate(&tc.question) {
+				got = append(got, e.Field+": "+e.Message)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Validate errors\ngot  %q\nwant %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateNormalizes(t *testing.T) {
+	q := model.Question{
+		Question:   "  2+2?  ",
+		Options:    []string{" 3 ", " 4"},
+		CorrectAns: " 4 ",
+		Reason:     " Arithmetic ",
+		Difficulty: " Easy ",
+		Tags:       []string{" Maths", "maths", "Basics "},
+	}
+	if errs := Validate(&q); errs != nil {
+		t.Fatalf("Validate = %v", errs)
+	}
+	want := model.Question{
+		Type:       model.QuestionSingleChoice,
+		Question:   "2+2?",
+		Options:    []string{"3", "4"},
+		CorrectAns: "4",
+		Reason:     "Arithmetic",
+		Difficulty: model.DifficultyEasy,
+		Tags:       []string{"maths", "basics"},
+	}
+	if !reflect.DeepEqual(q, want) {
+		t.Errorf("Validate left\n%+v\nwant\n%+v", q, want)
+	}
+
+	tf := model.Question{Type: model.QuestionTrueFalse, Question: "Sky is blue?", CorrectAns: " False "}
+	if errs := Validate(&tf); errs != nil {
+		t.Fatalf("Validate = %v", errs)
+	}
+	if !reflect.DeepEqual(tf.Options, []string{"true", "false"}) || tf.CorrectAns != "false" {
+		t.Errorf("true/false question normalized to options %q, answer %q", tf.Options, tf.CorrectAns)
+	}
+}
@@ -0,0 +1,45 @@
+package router
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/metrics"
+	"github.com/AniketGodambe/mongoapi/store"
+)
+
+// gaugeTimeout bounds the store queries behind the business gauges, which run
+// on every scrape.
+const gaugeTimeout = 5 * time.Second
+
+// registerGauges registers contact and question totals with reg, read from
+// stores at scrape time. A gauge whose query fails is left out of that scrape.
+func registerGauges(reg *metrics.Registry, stores store.Stores, logger *slog.Logger) {
+	reg.NewGaugeFunc("mongoapi_contacts", "Contacts not in the trash.", nil, func() []metrics.Sample {
+		ctx, cancel := context.WithTimeout(context.Background(), gaugeTimeout)
+		defer cancel()
+
+		count, err := stores.Contacts.Count(ctx)
+		if err != nil {
+			logger.Error("Error counting contacts for metrics", "err", err)
+			return nil
+		}
+		return []metrics.Sample{{Value: float64(count)}}
+	})
+
+	reg.NewGaugeFunc("mongoapi_questions", "Questions not in the trash, by visibility.", []string{"state"}, func() []metrics.Sample {
+		ctx, cancel := context.WithTimeout(context.Background(), gaugeTimeout)
+		defer cancel()
+
+		visible, hidden, err := stores.Questions.CountByVisibility(ctx)
+		if err != nil {
+			logger.Error("Error counting questions for metrics", "err", err)
+			return nil
+		}
+		return []metrics.Sample{
+			{Values: []string{"visible"}, Value: float64(visible)},
+			{Values: []string{"hidden"}, Value: float64(hidden)},
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package router
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/AniketGodambe/mongoapi/auth"
+	"github.com/AniketGodambe/mongoapi/metrics"
+	"github.com/AniketGodambe/mongoapi/store"
+)
+
+// TestRoutersKeepTheirOwnMetrics checks that a second router, as built by
+// another test or by mongoapictl's direct mode, leaves the gauges and request
+// counts of the first one alone.
+func TestRoutersKeepTheirOwnMetrics(t *testing.T) {
+	newRouter := func() http.Handler {
+		authenticator := auth.NewAuthenticator("", []auth.APIKey{{Key: testAdminKey, Subject: "admin", Role: auth.RoleAdmin}})
+		return Router(store.NewMemoryStores(), authenticator, 0, metrics.NewRegistry(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	}
+	first := newRouter()
+	second := newRouter()
+	if rec := serve(first, http.MethodPost, "/api/v2/contacts", testContact); rec.Code != http.StatusCreated {
+		t.Fatalf("creating contact: %d %s", rec.Code, rec.Body)
+	}
+
+	tests := []struct {
+		name string
+		h    http.Handler
+		want []string
+		// absent are lines the scrape must not contain
+		absent []string
+	}{
+		{
+			name:   "first",
+			h:      first,
+			want:   []string{"\nmongoapi_contacts 1\n", `mongoapi_http_requests_total{method="POST",route="/api/v2/contacts",status="201"} 1`},
+			absent: []string{"\nmongoapi_contacts 0\n"},
+		},
+		{
+			name:   "second",
+			h:      second,
+			want:   []string{"\nmongoapi_contacts 0\n"},
+			absent: []string{`method="POST"`},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serve(tc.h, http.MethodGet, "/metrics", "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET /metrics = %d", rec.Code)
+			}
+			body := rec.Body.String()
+			for _, want := range tc.want {
+				if !strings.Contains(body, want) {
+					t.Errorf("scrape lacks %q:\n%s", want, body)
+				}
+			}
+			for _, absent := range tc.absent {
+				if strings.Contains(body, absent) {
+					t.Errorf("scrape contains %q:\n%s", absent, body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/AniketGodambe/mongoapi/auth"
+	"github.com/AniketGodambe/mongoapi/metrics"
+	"github.com/AniketGodambe/mongoapi/model"
+	"github.com/AniketGodambe/mongoapi/store"
+)
+
+func TestAddQuestionDuplicate(t *testing.T) {
+	h := newTestRouter(t, false)
+
+	rec := serve(h, http.MethodPost, "/api/questions/add", testQuestion)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("POST duplicate question = %d, want 409: %s", rec.Code, rec.Body)
+	}
+	var resp model.Response
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Message != "Question already exists!" || resp.StatusCode != http.StatusConflict || resp.Data != "Error" {
+		t.Errorf("response = %+v, want the duplicate error envelope", resp)
+	}
+}
+
+var errUnavailable = errors.New("connection refused")
+
+// unavailableQuestions fails every lookup as an unreachable database would.
+type unavailableQuestions struct {
+	store.QuestionStore
+}
+
+func (unavailableQuestions) FindByID(context.Context, int) (*model.Question, error) {
+	return nil, errUnavailable
+}
+
+func TestQuestionLookupFailures(t *testing.T) {
+	routes := []struct{ method, target, body string }{
+		{method: "GET", target: "/api/getQuestionById?id=1"},
+		{method: "PUT", target: "/api/questions/questionVisibility", body: `{"id":1}`},
+	}
+	for _, route := range routes {
+		t.Run(route.method+" "+route.target, func(t *testing.T) {
+			stores := store.NewMemoryStores()
+			stores.Questions = unavailableQuestions{stores.Questions}
+			var logs bytes.Buffer
+			authenticator := auth.NewAuthenticator("", []auth.APIKey{{Key: testAdminKey, Subject: "admin", Role: auth.RoleAdmin}})
+			h := Router(stores, authenticator, 0, metrics.NewRegistry(), slog.New(slog.NewTextHandler(&logs, nil)))
+
+			rec := serve(h, route.method, route.target, route.body)
+			if rec.Code != http.StatusInternalServerError {
+				t.Errorf("status = %d, want 500: %s", rec.Code, rec.Body)
+			}
+			if !strings.Contains(logs.String(), `msg="Error loading question" request_id=`) || !strings.Contains(logs.String(), errUnavailable.Error()) {
+				t.Errorf("logs do not record the store error:\n%s", logs.String())
+			}
+		})
+	}
+}
+
+func TestLegacyQuestionsServedWithType(t *testing.T) {
+	stores := store.NewMemoryStores()
+	legacy := model.Question{ID: 1, Question: "What is 2+2?", Options: []string{"3", "4"}, CorrectAns: "4"}
+	if err := stores.Questions.Insert(t.Context(), legacy); err != nil {
+		t.Fatal(err)
+	}
+	authenticator := auth.NewAuthenticator("", []auth.APIKey{{Key: testAdminKey, Subject: "admin", Role: auth.RoleAdmin}})
+	h := Router(stores, authenticator, 0, metrics.NewRegistry(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for _, target := range []string{
+		"/api/questions/questionsList",
+		"/api/getQuestionById?id=1",
+		"/api/v2/questions",
+		"/api/v2/questions/1",
+	} {
+		rec := serve(h, http.MethodGet, target, "")
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s = %d: %s", target, rec.Code, rec.Body)
+			continue
+		}
+		if body := rec.Body.String(); !strings.Contains(body, `"type":"single_choice"`) || strings.Contains(body, `"type":""`) {
+			t.Errorf("GET %s does not serve the legacy question as single_choice: %s", target, body)
+		}
+	}
+}
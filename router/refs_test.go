@@ -0,0 +1,175 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AniketGodambe/mongoapi/auth"
+	"github.com/AniketGodambe/mongoapi/metrics"
+	"github.com/AniketGodambe/mongoapi/model"
+	"github.com/AniketGodambe/mongoapi/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const testAdminKey = "test-admin-key"
+
+const (
+	testContact  = `{"contact_name":"Asha Rao","mobile":"9876543210","preferred_channel":[],"preferred_language":[]}`
+	testQuestion = `{"question":"What is 2+2?","options":["3","4"],"correct_answer":"4","reason":"Arithmetic"}`
+)
+
+// knownObjectID is the ObjectID objectIDQuestions resolves to the first
+// question. The memory store draws its ObjectIDs at random and never hands
+// them out, so the test pins one.
+var knownObjectID = primitive.NewObjectID()
+
+type objectIDQuestions struct {
+	store.QuestionStore
+}
+
+func (s objectIDQuestions) FindByObjectID(ctx context.Context, id primitive.ObjectID) (*model.Question, error) {
+	if id != knownObjectID {
+		return nil, store.ErrNotFound
+	}
+	q, err := s.QuestionStore.FindByID(ctx, 1)
+	if err == store.ErrNotFound {
+		// Trashed questions keep their ObjectID
+		q, err = s.QuestionStore.FindTrashed(ctx, 1)
+	}
+	return q, err
+}
+
+// newTestRouter returns a router over fresh memory stores holding contact 1
+// and question 1, which is in the trash when trashed is set.
+func newTestRouter(t *testing.T, trashed bool) http.Handler {
+	t.Helper()
+	stores := store.NewMemoryStores()
+	stores.Questions = objectIDQuestions{stores.Questions}
+	authenticator := auth.NewAuthenticator("", []auth.APIKey{{Key: testAdminKey, Subject: "admin", Role: auth.RoleAdmin}})
+	h := Router(stores, authenticator, 0, metrics.NewRegistry(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for _, create := range []struct{ path, body string }{
+		{"/api/v2/contacts", testContact},
+		{"/api/v2/questions", testQuestion},
+	} {
+		rec := serve(h, http.MethodPost, create.path, create.body)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("POST %s: %d %s", create.path, rec.Code, rec.Body)
+		}
+		var resp struct {
+			Data struct {
+				ID int `json:"id"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Data.ID != 1 {
+			t.Fatalf("POST %s returned ID %d, err %v; want 1", create.path, resp.Data.ID, err)
+		}
+	}
+	if trashed {
+		if rec := serve(h, http.MethodDelete, "/api/v2/questions/1", ""); rec.Code != http.StatusNoContent {
+			t.Fatalf("trashing question 1: %d %s", rec.Code, rec.Body)
+		}
+	}
+	return h
+}
+
+func serve(h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("X-API-Key", testAdminKey)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestRefs sends every contact and question route the ID returned by create,
+// the ObjectID fallback, malformed refs and unknown refs. "{ref}" in the
+// target or body is replaced by the ref under test.
+func TestRefs(t *testing.T) {
+	routes := []struct {
+		method, target, body string
+		ok                   int
+		// inBody routes read a JSON number, so only numeric refs apply
+		inBody bool
+		// trashed routes act on question 1 after it was moved to the trash
+		trashed bool
+		// objectID is false for contacts, whose _id is their public ID
+		objectID bool
+	}{
+		// Contacts v1
+		{method: "PUT", target: "/api/addContact", body: `{"id":{ref},"contact_name":"Asha R"}`, ok: 200, inBody: true},
+		{method: "PATCH", target: "/api/contacts/{ref}", body: `{"age":35}`, ok: 200},
+		{method: "DELETE", target: "/api/delete?id={ref}", ok: 200},
+
+		// Contacts v2
+		{method: "GET", target: "/api/v2/contacts/{ref}", ok: 200},
+		{method: "PATCH", target: "/api/v2/contacts/{ref}", body: `{"age":35}`, ok: 200},
+		{method: "DELETE", target: "/api/v2/contacts/{ref}", ok: 204},
+
+		// Questions v1
+		{method: "PUT", target: "/api/questions/update", body: `{"id":{ref},"question":"What is 3+3?","options":["5","6"],"correct_answer":"6","reason":"Arithmetic"}`, ok: 200, inBody: true},
+		{method: "DELETE", target: "/api/questions/delete?id={ref}", ok: 200, objectID: true},
+		{method: "PUT", target: "/api/questions/questionVisibility", body: `{"id":{ref}}`, ok: 200, inBody: true},
+		{method: "GET", target: "/api/getQuestionById?id={ref}", ok: 200, objectID: true},
+
+		// Questions v2
+		{method: "GET", target: "/api/v2/questions/{ref}", ok: 200, objectID: true},
+		{method: "PUT", target: "/api/v2/questions/{ref}", body: `{"question":"What is 3+3?","options":["5","6"],"correct_answer":"6","reason":"Arithmetic"}`, ok: 200, objectID: true},
+		{method: "DELETE", target: "/api/v2/questions/{ref}", ok: 204, objectID: true},
+		{method: "POST", target: "/api/v2/questions/{ref}/restore", ok: 200, trashed: true, objectID: true},
+		{method: "PUT", target: "/api/v2/questions/{ref}/visibility", body: `{"hidden":true}`, ok: 200, objectID: true},
+		{method: "GET", target: "/api/v2/questions/{ref}/revisions", ok: 200, objectID: true},
+		{method: "GET", target: "/api/v2/questions/{ref}/revisions/diff?from=1&to=1", ok: 200, objectID: true},
+		{method: "GET", target: "/api/v2/questions/{ref}/revisions/1", ok: 200, objectID: true},
+		{method: "POST", target: "/api/v2/questions/{ref}/revisions/1/revert", ok: 200, objectID: true},
+	}
+
+	refs := []struct {
+		name, ref string
+		pathOnly  bool
+		want      func(ok int, objectID bool) int
+	}{
+		{name: "created", ref: "1", want: func(ok int, _ bool) int { return ok }},
+		{name: "objectid", ref: knownObjectID.Hex(), pathOnly: true, want: func(ok int, objectID bool) int {
+			if objectID {
+				return ok
+			}
+			return http.StatusBadRequest
+		}},
+		{name: "word", ref: "abc", want: func(int, bool) int { return http.StatusBadRequest }},
+		{name: "zero", ref: "0", want: func(int, bool) int { return http.StatusBadRequest }},
+		{name: "negative", ref: "-1", want: func(int, bool) int { return http.StatusBadRequest }},
+		{name: "short_hex", ref: "5f1d7a", pathOnly: true, want: func(int, bool) int { return http.StatusBadRequest }},
+		{name: "unknown", ref: "999", want: func(int, bool) int { return http.StatusNotFound }},
+		{name: "unknown_objectid", ref: primitive.NewObjectID().Hex(), pathOnly: true, want: func(_ int, objectID bool) int {
+			if objectID {
+				return http.StatusNotFound
+			}
+			return http.StatusBadRequest
+		}},
+	}
+
+	for _, route := range routes {
+		for _, ref := range refs {
+			if route.inBody && ref.pathOnly {
+				continue
+			}
+			name := route.method + " " + route.target + "/" + ref.name
+			t.Run(name, func(t *testing.T) {
+				h := newTestRouter(t, route.trashed)
+				target := strings.ReplaceAll(route.target, "{ref}", ref.ref)
+				body := strings.ReplaceAll(route.body, "{ref}", ref.ref)
+
+				rec := serve(h, route.method, target, body)
+				if want := ref.want(route.ok, route.objectID); rec.Code != want {
+					t.Errorf("%s %s = %d, want %d: %s", route.method, target, rec.Code, want, rec.Body)
+				}
+			})
+		}
+	}
+}
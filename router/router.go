@@ -1,33 +1,200 @@
 package router
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/auth"
 	"github.com/AniketGodambe/mongoapi/controller"
+	"github.com/AniketGodambe/mongoapi/logging"
+	"github.com/AniketGodambe/mongoapi/metrics"
+	"github.com/AniketGodambe/mongoapi/openapi"
+	"github.com/AniketGodambe/mongoapi/store"
 	"github.com/gorilla/mux"
 )
 
-func Router() *mux.Router {
+// The v1 contact and question routes are kept as aliases of /api/v2 until the
+// sunset date. See RFC 9745 (Deprecation) and RFC 8594 (Sunset).
+var (
+	v1Deprecated = time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+	v1Sunset     = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+)
+
+// legacyPeekBytes bounds how much of a request body legacy reads to find
+// the ID of the resource it names.
+const legacyPeekBytes = 64 << 10
+
+// legacy marks a v1 route as deprecated, pointing clients at its successor.
+// An {id} in successor is filled from the route's id variable, the id query
+// parameter or the id field of a JSON body, in that order; without one the
+// link points at the collection.
+func legacy(successor string, h http.HandlerFunc) http.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(v1Deprecated.Unix(), 10)
+	sunset := v1Sunset.Format(http.TimeFormat)
+	collection, _, templated := strings.Cut(successor, "/{id}")
+	return func(w http.ResponseWriter, r *http.Request) {
+		target := successor
+		if templated {
+			if id := legacyID(r); id != "" {
+				target = strings.Replace(successor, "{id}", url.PathEscape(id), 1)
+			} else {
+				target = collection
+			}
+		}
+		w.Header().Set("Deprecation", deprecation)
+		w.Header().Set("Sunset", sunset)
+		w.Header().Set("Link", "<"+target+`>; rel="successor-version"`)
+		h(w, r)
+	}
+}
+
+// legacyID finds the ID a v1 request refers to. Reading it from the body
+// leaves the body intact for the handler.
+func legacyID(r *http.Request) string {
+	if id := mux.Vars(r)["id"]; id != "" {
+		return id
+	}
+	if id := r.URL.Query().Get("id"); id != "" {
+		return id
+	}
+	if r.Body == nil {
+		return ""
+	}
+
+	peeked, _ := io.ReadAll(io.LimitReader(r.Body, legacyPeekBytes))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(peeked), r.Body), r.Body}
+
+	var body struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if json.Unmarshal(peeked, &body) != nil || len(body.ID) == 0 {
+		return ""
+	}
+	var ref string
+	if json.Unmarshal(body.ID, &ref) == nil {
+		return ref
+	}
+	var id int
+	if json.Unmarshal(body.ID, &id) == nil && id > 0 {
+		return strconv.Itoa(id)
+	}
+	return ""
+}
+
+// Router wires the API routes to handlers backed by the given stores. Contact
+// and question management needs an authenticated caller with a suitable role;
+// the quiz routes stay public. Every request, matched or not, gets a request
+// ID and an access log line on logger, and is counted in the Prometheus
+// metrics served at /metrics. The router registers its request metrics and
+// business gauges with reg, which must not be shared with another router;
+// /metrics serves reg followed by the process-wide metrics.Default.
+func Router(stores store.Stores, authenticator *auth.Authenticator, retention time.Duration, reg *metrics.Registry, logger *slog.Logger) *mux.Router {
 	router := mux.NewRouter()
+	accessLog := logging.Middleware(logger)
+	counted := metrics.NewHTTP(reg).Middleware
+	router.Use(counted)
+	router.Use(accessLog)
+	router.Use(authenticator.Middleware)
+	router.NotFoundHandler = counted(accessLog(http.NotFoundHandler()))
+	router.MethodNotAllowedHandler = counted(accessLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})))
+	c := controller.New(stores, retention, authenticator)
+	registerGauges(reg, stores, logger)
 
-	// Get List of Contacts API
-	router.HandleFunc("/api/getContacts", controller.GetAllContactHandler).Methods("GET")
+	// Contacts API (v1, deprecated)
+	router.HandleFunc("/api/getContacts", legacy("/api/v2/contacts", auth.Require(auth.RoleViewer, c.GetAllContactHandler))).Methods("GET")
 
-	router.HandleFunc("/api/addContact", controller.CreateContactHandler).Methods("POST")
+	router.HandleFunc("/api/addContact", legacy("/api/v2/contacts", auth.Require(auth.RoleEditor, c.CreateContactHandler))).Methods("POST")
 
-	router.HandleFunc("/api/addContact", controller.UpdateContactHandler).Methods("PUT")
+	router.HandleFunc("/api/addContact", legacy("/api/v2/contacts/{id}", auth.Require(auth.RoleEditor, c.UpdateContactHandler))).Methods("PUT")
 
-	router.HandleFunc("/api/delete", controller.DeleteOneContactHandler).Methods("DELETE")
+	router.HandleFunc("/api/contacts/{id}", legacy("/api/v2/contacts/{id}", auth.Require(auth.RoleEditor, c.PatchContactHandler))).Methods("PATCH")
 
-	router.HandleFunc("/api/deleteAll", controller.DeleteAllContactHandler).Methods("DELETE")
+	router.HandleFunc("/api/delete", legacy("/api/v2/contacts/{id}", auth.Require(auth.RoleEditor, c.DeleteOneContactHandler))).Methods("DELETE")
 
-	// Questions API
-	router.HandleFunc("/api/questions/add", controller.AddQuestionHandler).Methods("POST")
-	router.HandleFunc("/api/questions/update", controller.UpdateQuestionHandler).Methods("PUT")
-	router.HandleFunc("/api/questions/delete", controller.DeleteQuestionHandler).Methods("DELETE")
-	router.HandleFunc("/api/questions/questionVisibility", controller.ToggleQuestionVisibilityHandler).Methods("PUT")
-	router.HandleFunc("/api/questions/questionsList", controller.GetAllQuestionsHandler).Methods("GET")
+	router.HandleFunc("/api/deleteAll", legacy("/api/v2/contacts", auth.Require(auth.RoleAdmin, c.DeleteAllContactHandler))).Methods("DELETE")
 
-	router.HandleFunc("/api/getQuestionById", controller.GetQuestionByIdHandler).Methods("GET")
+	router.HandleFunc("/api/contacts/import", legacy("/api/v2/contacts/import", auth.Require(auth.RoleEditor, c.ImportContactsHandler))).Methods("POST")
 
-	return router
+	router.HandleFunc("/api/contacts/export", legacy("/api/v2/contacts/export", auth.Require(auth.RoleViewer, c.ExportContactsHandler))).Methods("GET")
+
+	// Questions API (v1, deprecated)
+	router.HandleFunc("/api/questions/add", legacy("/api/v2/questions", auth.Require(auth.RoleEditor, c.AddQuestionHandler))).Methods("POST")
+	router.HandleFunc("/api/questions/update", legacy("/api/v2/questions/{id}", auth.Require(auth.RoleEditor, c.UpdateQuestionHandler))).Methods("PUT")
+	router.HandleFunc("/api/questions/delete", legacy("/api/v2/questions/{id}", auth.Require(auth.RoleAdmin, c.DeleteQuestionHandler))).Methods("DELETE")
+	router.HandleFunc("/api/questions/questionVisibility", legacy("/api/v2/questions/{id}/visibility", auth.Require(auth.RoleEditor, c.ToggleQuestionVisibilityHandler))).Methods("PUT")
+	router.HandleFunc("/api/questions/categories", legacy("/api/v2/questions/categories", auth.Require(auth.RoleViewer, c.GetQuestionCategoriesHandler))).Methods("GET")
+	router.HandleFunc("/api/questions/tags", legacy("/api/v2/questions/tags", auth.Require(auth.RoleViewer, c.GetQuestionTagsHandler))).Methods("GET")
+	router.HandleFunc("/api/questions/questionsList", legacy("/api/v2/questions", auth.Require(auth.RoleViewer, c.GetAllQuestionsHandler))).Methods("GET")
 
+	router.HandleFunc("/api/getQuestionById", legacy("/api/v2/questions/{id}", auth.Require(auth.RoleViewer, c.GetQuestionByIdHandler))).Methods("GET")
+
+	// Contacts API v2
+	v2 := router.PathPrefix("/api/v2").Subrouter()
+	v2.HandleFunc("/contacts", auth.Require(auth.RoleViewer, c.GetAllContactHandler)).Methods("GET")
+	v2.HandleFunc("/contacts", auth.Require(auth.RoleEditor, c.CreateContactV2Handler)).Methods("POST")
+	v2.HandleFunc("/contacts", auth.Require(auth.RoleAdmin, c.DeleteAllContactHandler)).Methods("DELETE")
+	v2.HandleFunc("/contacts/trash", auth.Require(auth.RoleEditor, c.GetContactTrashHandler)).Methods("GET")
+	v2.HandleFunc("/contacts/import", auth.Require(auth.RoleEditor, c.ImportContactsHandler)).Methods("POST")
+	v2.HandleFunc("/contacts/export", auth.Require(auth.RoleViewer, c.ExportContactsHandler)).Methods("GET")
+	v2.HandleFunc("/contacts/{id}", auth.Require(auth.RoleViewer, c.GetContactHandler)).Methods("GET")
+	v2.HandleFunc("/contacts/{id}", auth.Require(auth.RoleEditor, c.PatchContactHandler)).Methods("PATCH")
+	v2.HandleFunc("/contacts/{id}", auth.Require(auth.RoleEditor, c.DeleteContactHandler)).Methods("DELETE")
+	v2.HandleFunc("/contacts/{id}/restore", auth.Require(auth.RoleEditor, c.RestoreContactHandler)).Methods("POST")
+
+	// Questions API v2
+	v2.HandleFunc("/questions", auth.Require(auth.RoleViewer, c.GetAllQuestionsHandler)).Methods("GET")
+	v2.HandleFunc("/questions", auth.Require(auth.RoleEditor, c.CreateQuestionV2Handler)).Methods("POST")
+	v2.HandleFunc("/questions/categories", auth.Require(auth.RoleViewer, c.GetQuestionCategoriesHandler)).Methods("GET")
+	v2.HandleFunc("/questions/tags", auth.Require(auth.RoleViewer, c.GetQuestionTagsHandler)).Methods("GET")
+	v2.HandleFunc("/questions/trash", auth.Require(auth.RoleEditor, c.GetQuestionTrashHandler)).Methods("GET")
+	v2.HandleFunc("/questions/{id}", auth.Require(auth.RoleViewer, c.GetQuestionHandler)).Methods("GET")
+	v2.HandleFunc("/questions/{id}", auth.Require(auth.RoleEditor, c.ReplaceQuestionHandler)).Methods("PUT")
+	v2.HandleFunc("/questions/{id}", auth.Require(auth.RoleAdmin, c.DeleteQuestionV2Handler)).Methods("DELETE")
+	v2.HandleFunc("/questions/{id}/restore", auth.Require(auth.RoleAdmin, c.RestoreQuestionHandler)).Methods("POST")
+	v2.HandleFunc("/questions/{id}/visibility", auth.Require(auth.RoleEditor, c.SetQuestionVisibilityHandler)).Methods("PUT")
+	v2.HandleFunc("/questions/{id}/revisions", auth.Require(auth.RoleViewer, c.GetQuestionRevisionsHandler)).Methods("GET")
+	v2.HandleFunc("/questions/{id}/revisions/diff", auth.Require(auth.RoleViewer, c.DiffQuestionRevisionsHandler)).Methods("GET")
+	v2.HandleFunc("/questions/{id}/revisions/{rev:[0-9]+}", auth.Require(auth.RoleViewer, c.GetQuestionRevisionHandler)).Methods("GET")
+	v2.HandleFunc("/questions/{id}/revisions/{rev:[0-9]+}/revert", auth.Require(auth.RoleEditor, c.RevertQuestionHandler)).Methods("POST")
+
+	// Trash
+	v2.HandleFunc("/trash/purge", auth.Require(auth.RoleAdmin, c.PurgeTrashHandler)).Methods("POST")
+
+	// Audit log
+	v2.HandleFunc("/audit", auth.Require(auth.RoleAdmin, c.GetAuditLogHandler)).Methods("GET")
+
+	// Public Quiz API
+	router.HandleFunc("/api/quiz/questions", c.GetQuizQuestionsHandler).Methods("GET")
+	router.HandleFunc("/api/quiz/submit", c.SubmitQuizHandler).Methods("POST")
+
+	// Quiz Attempts API; attempts belong to the authenticated caller
+	router.HandleFunc("/api/quiz/attempts", auth.Require(auth.RoleViewer, c.StartAttemptHandler)).Methods("POST")
+	router.HandleFunc("/api/quiz/attempts", auth.Require(auth.RoleViewer, c.GetAttemptsHandler)).Methods("GET")
+	router.HandleFunc("/api/quiz/attempts/{id:[0-9]+}", auth.Require(auth.RoleViewer, c.GetAttemptHandler)).Methods("GET")
+	router.HandleFunc("/api/quiz/attempts/{id:[0-9]+}/submit", auth.Require(auth.RoleViewer, c.SubmitAttemptHandler)).Methods("POST")
+
+	// Health checks
+	router.HandleFunc("/healthz", c.HealthzHandler).Methods("GET")
+	router.HandleFunc("/readyz", c.ReadyzHandler).Methods("GET")
+
+	// Prometheus metrics
+	router.Handle("/metrics", metrics.Handler(reg, metrics.Default)).Methods("GET")
+
+	// API description
+	router.Handle("/openapi.json", openapi.Handler()).Methods("GET")
+	router.Handle("/docs", openapi.DocsHandler()).Methods("GET")
+
+	return router
 }
@@ -0,0 +1,26 @@
+package router
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/AniketGodambe/mongoapi/auth"
+	"github.com/AniketGodambe/mongoapi/metrics"
+	"github.com/AniketGodambe/mongoapi/openapi"
+	"github.com/AniketGodambe/mongoapi/store"
+)
+
+// TestRoutesMatchOpenAPI keeps the OpenAPI document and the routes in step:
+// every route is documented and every documented operation is routed.
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	router := Router(store.NewMemoryStores(), auth.NewAuthenticator("", nil), 0, metrics.NewRegistry(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	problems, err := openapi.Check(router)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, problem := range problems {
+		t.Error(problem)
+	}
+}
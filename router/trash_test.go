@@ -0,0 +1,100 @@
+package router
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/auth"
+	"github.com/AniketGodambe/mongoapi/metrics"
+	"github.com/AniketGodambe/mongoapi/model"
+	"github.com/AniketGodambe/mongoapi/store"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// newReplicas returns n routers over the same stores and JWT secret, as
+// replicas behind a load balancer would be.
+func newReplicas(stores store.Stores, n int) []http.Handler {
+	replicas := make([]http.Handler, n)
+	for i := range replicas {
+		authenticator := auth.NewAuthenticator(testSecret, []auth.APIKey{{Key: testAdminKey, Subject: "admin", Role: auth.RoleAdmin}})
+		replicas[i] = Router(stores, authenticator, 0, metrics.NewRegistry(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	}
+	return replicas
+}
+
+// confirmToken asks for the confirm token of a destructive request.
+func confirmToken(t *testing.T, h http.Handler, method, target string) string {
+	t.Helper()
+	rec := serve(h, method, target, "")
+	if rec.Code != http.StatusPreconditionRequired {
+		t.Fatalf("%s %s without a token = %d, want 428: %s", method, target, rec.Code, rec.Body)
+	}
+	var resp struct {
+		Data model.ConfirmToken `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Data.Token == "" {
+		t.Fatalf("no confirm token in 428 response: %v", err)
+	}
+	return resp.Data.Token
+}
+
+func TestDeleteAllConfirmedByAnotherReplica(t *testing.T) {
+	stores := store.NewMemoryStores()
+	replicas := newReplicas(stores, 2)
+	if rec := serve(replicas[0], http.MethodPost, "/api/v2/contacts", testContact); rec.Code != http.StatusCreated {
+		t.Fatalf("creating contact: %d %s", rec.Code, rec.Body)
+	}
+
+	token := confirmToken(t, replicas[0], http.MethodDelete, "/api/v2/contacts")
+	if rec := serve(replicas[1], http.MethodDelete, "/api/v2/contacts?confirm=bad"+token, ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("DELETE with a bad token = %d, want 400: %s", rec.Code, rec.Body)
+	}
+	rec := serve(replicas[1], http.MethodDelete, "/api/v2/contacts?confirm="+token, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("DELETE on the other replica = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if contacts, _ := stores.Contacts.ListTrash(t.Context()); len(contacts) != 1 {
+		t.Errorf("trash holds %d contacts, want 1", len(contacts))
+	}
+}
+
+func TestPurgeWholeTrashNeedsConfirming(t *testing.T) {
+	stores := store.NewMemoryStores()
+	h := newReplicas(stores, 1)[0]
+	if rec := serve(h, http.MethodPost, "/api/v2/contacts", testContact); rec.Code != http.StatusCreated {
+		t.Fatalf("creating contact: %d %s", rec.Code, rec.Body)
+	}
+	if rec := serve(h, http.MethodDelete, "/api/v2/contacts/1", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("trashing contact: %d %s", rec.Code, rec.Body)
+	}
+
+	token := confirmToken(t, h, http.MethodPost, "/api/v2/trash/purge")
+	deleteAll := confirmToken(t, h, http.MethodDelete, "/api/v2/contacts")
+	if rec := serve(h, http.MethodPost, "/api/v2/trash/purge?confirm="+deleteAll, ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("purge with the delete-all token = %d, want 400: %s", rec.Code, rec.Body)
+	}
+	if contacts, _ := stores.Contacts.ListTrash(t.Context()); len(contacts) != 1 {
+		t.Fatalf("trash holds %d contacts before confirming, want 1", len(contacts))
+	}
+
+	rec := serve(h, http.MethodPost, "/api/v2/trash/purge?confirm="+token, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("confirmed purge = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if contacts, _ := stores.Contacts.ListTrash(t.Context()); len(contacts) != 0 {
+		t.Errorf("trash holds %d contacts after purging, want 0", len(contacts))
+	}
+}
+
+func TestPurgeExpiredTrashNeedsNoConfirming(t *testing.T) {
+	authenticator := auth.NewAuthenticator(testSecret, []auth.APIKey{{Key: testAdminKey, Subject: "admin", Role: auth.RoleAdmin}})
+	h := Router(store.NewMemoryStores(), authenticator, time.Hour, metrics.NewRegistry(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if rec := serve(h, http.MethodPost, "/api/v2/trash/purge", ""); rec.Code != http.StatusOK {
+		t.Errorf("purge with a retention = %d, want 200: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/metrics"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var dbDuration = metrics.NewHistogramVec("mongoapi_db_operation_duration_seconds",
+	"Time taken by MongoDB collection calls, by collection, operation and outcome.",
+	metrics.DefaultBuckets, "collection", "operation", "outcome")
+
+// collection wraps the calls the Mongo stores make on a collection so each
+// one is timed. Outcomes are "ok", "not_found" for single-document lookups
+// that matched nothing, and "error".
+type collection struct {
+	*mongo.Collection
+}
+
+func instrument(c *mongo.Collection) *collection {
+	return &collection{c}
+}
+
+// observe records a call that started at start and ended with err.
+func (c *collection) observe(operation string, start time.Time, err error) {
+	outcome := "ok"
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		outcome = "not_found"
+	} else if err != nil {
+		outcome = "error"
+	}
+	dbDuration.Observe(time.Since(start).Seconds(), c.Name(), operation, outcome)
+}
+
+func (c *collection) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	start := time.Now()
+	cursor, err := c.Collection.Find(ctx, filter, opts...)
+	c.observe("Find", start, err)
+	return cursor, err
+}
+
+func (c *collection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	start := time.Now()
+	result := c.Collection.FindOne(ctx, filter, opts...)
+	c.observe("FindOne", start, result.Err())
+	return result
+}
+
+func (c *collection) FindOneAndUpdate(ctx context.Context, filter, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+	start := time.Now()
+	result := c.Collection.FindOneAndUpdate(ctx, filter, update, opts...)
+	c.observe("FindOneAndUpdate", start, result.Err())
+	return result
+}
+
+func (c *collection) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	start := time.Now()
+	result, err := c.Collection.InsertOne(ctx, document, opts...)
+	c.observe("InsertOne", start, err)
+	return result, err
+}
+
+func (c *collection) UpdateOne(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	start := time.Now()
+	result, err := c.Collection.UpdateOne(ctx, filter, update, opts...)
+	c.observe("UpdateOne", start, err)
+	return result, err
+}
+
+func (c *collection) UpdateMany(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	start := time.Now()
+	result, err := c.Collection.UpdateMany(ctx, filter, update, opts...)
+	c.observe("UpdateMany", start, err)
+	return result, err
+}
+
+func (c *collection) DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	start := time.Now()
+	result, err := c.Collection.DeleteMany(ctx, filter, opts...)
+	c.observe("DeleteMany", start, err)
+	return result, err
+}
+
+func (c *collection) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	start := time.Now()
+	count, err := c.Collection.CountDocuments(ctx, filter, opts...)
+	c.observe("CountDocuments", start, err)
+	return count, err
+}
+
+func (c *collection) Aggregate(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (*mongo.Cursor, error) {
+	start := time.Now()
+	cursor, err := c.Collection.Aggregate(ctx, pipeline, opts...)
+	c.observe("Aggregate", start, err)
+	return cursor, err
+}
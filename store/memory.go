@@ -0,0 +1,824 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/language"
+	"github.com/AniketGodambe/mongoapi/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NewMemoryStores returns empty stores that keep everything in process memory.
+// They need no database and are meant for local runs and tests.
+func NewMemoryStores() Stores {
+	return Stores{
+		Contacts:  NewMemoryContactStore(),
+		Questions: NewMemoryQuestionStore(),
+		Attempts:  NewMemoryAttemptStore(),
+		Revisions: NewMemoryRevisionStore(),
+		Audit:     NewMemoryAuditStore(),
+		IDs:       NewMemorySequence(),
+	}
+}
+
+// MemorySequence implements Sequence with in-process counters.
+type MemorySequence struct {
+	mu   sync.Mutex
+	next map[string]int
+}
+
+func NewMemorySequence() *MemorySequence {
+	return &MemorySequence{next: map[string]int{}}
+}
+
+func (s *MemorySequence) Next(ctx context.Context, name string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.next[name]++
+	return s.next[name], nil
+}
+
+// MemoryContactStore implements ContactStore in memory.
+type MemoryContactStore struct {
+	mu      sync.RWMutex
+	records []model.Contact
+}
+
+func NewMemoryContactStore() *MemoryContactStore {
+	return &MemoryContactStore{}
+}
+
+func (s *MemoryContactStore) List(ctx context.Context, query ContactQuery) ([]model.Contact, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var contacts []model.Contact
+	for _, rec := range s.records {
+		if rec.DeletedAt == nil && matchesContact(rec, query) {
+			contacts = append(contacts, cloneContact(rec))
+		}
+	}
+	return contacts, nil
+}
+
+func matchesContact(c model.Contact, query ContactQuery) bool {
+	if query.Channel != "" && !slices.ContainsFunc(c.PreferredChannel, func(ch model.Channel) bool {
+		return ch.ChannelName == query.Channel
+	}) {
+		return false
+	}
+	if query.Language != "" && !slices.ContainsFunc(c.PreferredLanguage, func(l string) bool {
+		code, _ := language.Normalize(l)
+		return code == query.Language
+	}) {
+		return false
+	}
+	return true
+}
+
+func (s *MemoryContactStore) Each(ctx context.Context, fn func(model.Contact) error) error {
+	// Iterate over a copy so fn may call back into the store
+	contacts, _ := s.List(ctx, ContactQuery{})
+	for _, contact := range contacts {
+		if err := fn(contact); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryContactStore) FindByID(ctx context.Context, id int) (*model.Contact, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, rec := range s.records {
+		if rec.ID == id && rec.DeletedAt == nil {
+			contact := cloneContact(rec)
+			return &contact, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryContactStore) FindByMobile(ctx context.Context, mobile string) (*model.Contact, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, rec := range s.records {
+		if rec.Mobile == mobile {
+			contact := cloneContact(rec)
+			return &contact, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryContactStore) Count(ctx context.Context) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var n int64
+	for _, rec := range s.records {
+		if rec.DeletedAt == nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *MemoryContactStore) Insert(ctx context.Context, contact model.Contact) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rec := range s.records {
+		if (contact.ID != 0 && rec.ID == contact.ID) ||
+			(contact.Mobile != "" && rec.Mobile == contact.Mobile) {
+			return ErrDuplicate
+		}
+	}
+
+	s.records = append(s.records, cloneContact(contact))
+	return nil
+}
+
+func (s *MemoryContactStore) Patch(ctx context.Context, id int, patch model.ContactPatch) (*model.Contact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.records {
+		c := &s.records[i]
+		if c.ID != id || c.DeletedAt != nil {
+			continue
+		}
+		if patch.Mobile != nil {
+			for _, other := range s.records {
+				if other.ID != id && other.Mobile == *patch.Mobile {
+					return nil, ErrDuplicate
+				}
+			}
+		}
+		patch.Apply(c)
+		updated := cloneContact(*c)
+		return &updated, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryContactStore) Trash(ctx context.Context, id int, at time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.records {
+		c := &s.records[i]
+		if c.ID == id && c.DeletedAt == nil {
+			c.DeletedAt = &at
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *MemoryContactStore) TrashAll(ctx context.Context, at time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for i := range s.records {
+		if c := &s.records[i]; c.DeletedAt == nil {
+			c.DeletedAt = &at
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *MemoryContactStore) ListTrash(ctx context.Context) ([]model.Contact, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var contacts []model.Contact
+	for _, rec := range s.records {
+		if rec.DeletedAt != nil {
+			contacts = append(contacts, cloneContact(rec))
+		}
+	}
+	sort.SliceStable(contacts, func(i, j int) bool { return contacts[i].DeletedAt.After(*contacts[j].DeletedAt) })
+	return contacts, nil
+}
+
+func (s *MemoryContactStore) FindTrashed(ctx context.Context, id int) (*model.Contact, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, rec := range s.records {
+		if rec.ID == id && rec.DeletedAt != nil {
+			contact := cloneContact(rec)
+			return &contact, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryContactStore) Restore(ctx context.Context, id int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.records {
+		c := &s.records[i]
+		if c.ID == id && c.DeletedAt != nil {
+			c.DeletedAt = nil
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *MemoryContactStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.records[:0]
+	for _, rec := range s.records {
+		if rec.DeletedAt == nil || !rec.DeletedAt.Before(before) {
+			kept = append(kept, rec)
+		}
+	}
+	n := int64(len(s.records) - len(kept))
+	s.records = kept
+	return n, nil
+}
+
+type questionRecord struct {
+	oid      primitive.ObjectID
+	question model.Question
+}
+
+// MemoryQuestionStore implements QuestionStore in memory.
+type MemoryQuestionStore struct {
+	mu      sync.RWMutex
+	records []questionRecord
+}
+
+func NewMemoryQuestionStore() *MemoryQuestionStore {
+	return &MemoryQuestionStore{}
+}
+
+func (s *MemoryQuestionStore) List(ctx context.Context) ([]model.Question, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var questions []model.Question
+	for _, rec := range s.records {
+		if rec.question.DeletedAt == nil {
+			questions = append(questions, cloneQuestion(rec.question))
+		}
+	}
+	return questions, nil
+}
+
+func (s *MemoryQuestionStore) Find(ctx context.Context, query QuestionQuery) (QuestionPage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matched []model.Question
+	for _, rec := range s.records {
+		if matchesQuestion(rec.question, query) {
+			matched = append(matched, rec.question)
+		}
+	}
+	total := int64(len(matched))
+
+	less := func(a, b model.Question) bool {
+		var c int
+		switch query.SortBy {
+		case SortByCreatedAt:
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		case SortByLastModified:
+			c = a.LastModified.Compare(b.LastModified)
+		}
+		if c == 0 {
+			c = cmp.Compare(a.ID, b.ID)
+		}
+		if query.Desc {
+			return c > 0
+		}
+		return c < 0
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return less(matched[i], matched[j]) })
+
+	if query.After != nil {
+		after := model.Question{ID: query.After.ID, CreatedAt: query.After.Time, LastModified: query.After.Time}
+		i := 0
+		for i < len(matched) && !less(after, matched[i]) {
+			i++
+		}
+		matched = matched[i:]
+	}
+
+	matched = matched[min(query.Skip, len(matched)):]
+
+	page := QuestionPage{Total: total}
+	if query.Limit > 0 && len(matched) > query.Limit {
+		matched = matched[:query.Limit]
+		page.HasMore = true
+	}
+	for _, q := range matched {
+		page.Questions = append(page.Questions, cloneQuestion(q))
+	}
+	return page, nil
+}
+
+func matchesQuestion(q model.Question, query QuestionQuery) bool {
+	if q.DeletedAt != nil {
+		return false
+	}
+	if query.Hidden != nil && q.Hidden != *query.Hidden {
+		return false
+	}
+	if query.Search != "" && !strings.Contains(strings.ToLower(q.Question), strings.ToLower(query.Search)) {
+		return false
+	}
+	if query.Category != "" && q.Category != query.Category {
+		return false
+	}
+	for _, tag := range query.Tags {
+		if !slices.Contains(q.Tags, tag) {
+			return false
+		}
+	}
+	if query.Difficulty != "" && q.Difficulty != query.Difficulty {
+		return false
+	}
+	return true
+}
+
+func (s *MemoryQuestionStore) FindByID(ctx context.Context, id int) (*model.Question, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, rec := range s.records {
+		if rec.question.ID == id && rec.question.DeletedAt == nil {
+			question := cloneQuestion(rec.question)
+			return &question, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryQuestionStore) FindByObjectID(ctx context.Context, id primitive.ObjectID) (*model.Question, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, rec := range s.records {
+		if rec.oid == id {
+			question := cloneQuestion(rec.question)
+			return &question, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryQuestionStore) Sample(ctx context.Context, n int) ([]model.Question, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var questions []model.Question
+	for _, i := range rand.Perm(len(s.records)) {
+		if len(questions) == n {
+			break
+		}
+		if q := s.records[i].question; !q.Hidden && q.DeletedAt == nil {
+			questions = append(questions, cloneQuestion(q))
+		}
+	}
+	return questions, nil
+}
+
+func (s *MemoryQuestionStore) ExistsByText(ctx context.Context, text string, excludeID int) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, rec := range s.records {
+		if rec.question.Question == text && rec.question.ID != excludeID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *MemoryQuestionStore) Count(ctx context.Context) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var n int64
+	for _, rec := range s.records {
+		if rec.question.DeletedAt == nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *MemoryQuestionStore) CountByVisibility(ctx context.Context) (int64, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var visible, hidden int64
+	for _, rec := range s.records {
+		switch {
+		case rec.question.DeletedAt != nil:
+		case rec.question.Hidden:
+			hidden++
+		default:
+			visible++
+		}
+	}
+	return visible, hidden, nil
+}
+
+func (s *MemoryQuestionStore) Categories(ctx context.Context) ([]model.TermCount, error) {
+	return s.countTerms(func(q model.Question) []string {
+		if q.Category == "" {
+			return nil
+		}
+		return []string{q.Category}
+	}), nil
+}
+
+func (s *MemoryQuestionStore) Tags(ctx context.Context) ([]model.TermCount, error) {
+	return s.countTerms(func(q model.Question) []string { return q.Tags }), nil
+}
+
+// countTerms counts the values terms returns across live questions, most used first.
+func (s *MemoryQuestionStore) countTerms(terms func(model.Question) []string) []model.TermCount {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	index := map[string]int{}
+	counts := []model.TermCount{}
+	for _, rec := range s.records {
+		if rec.question.DeletedAt != nil {
+			continue
+		}
+		for _, term := range terms(rec.question) {
+			i, ok := index[term]
+			if !ok {
+				i = len(counts)
+				index[term] = i
+				counts = append(counts, model.TermCount{Name: term})
+			}
+			counts[i].Count++
+		}
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
+}
+
+func (s *MemoryQuestionStore) Insert(ctx context.Context, question model.Question) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rec := range s.records {
+		if rec.question.ID == question.ID || rec.question.Question == question.Question {
+			return ErrDuplicate
+		}
+	}
+
+	s.records = append(s.records, questionRecord{oid: primitive.NewObjectID(), question: cloneQuestion(question)})
+	return nil
+}
+
+func (s *MemoryQuestionStore) Update(ctx context.Context, question model.Question) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rec := range s.records {
+		if rec.question.ID != question.ID && rec.question.Question == question.Question {
+			return 0, ErrDuplicate
+		}
+	}
+
+	for i := range s.records {
+		q := &s.records[i].question
+		if q.ID != question.ID || q.DeletedAt != nil {
+			continue
+		}
+		updated := cloneQuestion(question)
+		updated.CreatedAt = q.CreatedAt
+		*q = updated
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (s *MemoryQuestionStore) SetHidden(ctx context.Context, id int, hidden bool) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.records {
+		q := &s.records[i].question
+		if q.ID == id && q.DeletedAt == nil {
+			q.Hidden = hidden
+			q.LastModified = time.Now()
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *MemoryQuestionStore) Trash(ctx context.Context, id int, at time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.records {
+		q := &s.records[i].question
+		if q.ID == id && q.DeletedAt == nil {
+			q.DeletedAt = &at
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *MemoryQuestionStore) ListTrash(ctx context.Context) ([]model.Question, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var questions []model.Question
+	for _, rec := range s.records {
+		if rec.question.DeletedAt != nil {
+			questions = append(questions, cloneQuestion(rec.question))
+		}
+	}
+	sort.SliceStable(questions, func(i, j int) bool { return questions[i].DeletedAt.After(*questions[j].DeletedAt) })
+	return questions, nil
+}
+
+func (s *MemoryQuestionStore) FindTrashed(ctx context.Context, id int) (*model.Question, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, rec := range s.records {
+		if rec.question.ID == id && rec.question.DeletedAt != nil {
+			question := cloneQuestion(rec.question)
+			return &question, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryQuestionStore) Restore(ctx context.Context, id int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.records {
+		q := &s.records[i].question
+		if q.ID == id && q.DeletedAt != nil {
+			q.DeletedAt = nil
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *MemoryQuestionStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.records[:0]
+	for _, rec := range s.records {
+		if rec.question.DeletedAt == nil || !rec.question.DeletedAt.Before(before) {
+			kept = append(kept, rec)
+		}
+	}
+	n := int64(len(s.records) - len(kept))
+	s.records = kept
+	return n, nil
+}
+
+// MemoryAttemptStore implements AttemptStore in memory.
+type MemoryAttemptStore struct {
+	mu       sync.RWMutex
+	attempts []model.Attempt
+}
+
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{}
+}
+
+func (s *MemoryAttemptStore) Insert(ctx context.Context, attempt model.Attempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range s.attempts {
+		if a.ID == attempt.ID {
+			return ErrDuplicate
+		}
+	}
+	s.attempts = append(s.attempts, cloneAttempt(attempt))
+	return nil
+}
+
+func (s *MemoryAttemptStore) FindByID(ctx context.Context, id int) (*model.Attempt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, a := range s.attempts {
+		if a.ID == id {
+			attempt := cloneAttempt(a)
+			return &attempt, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryAttemptStore) ListByUser(ctx context.Context, userID string) ([]model.Attempt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var attempts []model.Attempt
+	for i := len(s.attempts) - 1; i >= 0; i-- {
+		if s.attempts[i].UserID == userID {
+			attempts = append(attempts, cloneAttempt(s.attempts[i]))
+		}
+	}
+	return attempts, nil
+}
+
+func (s *MemoryAttemptStore) Submit(ctx context.Context, attempt model.Attempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.attempts {
+		a := &s.attempts[i]
+		if a.ID != attempt.ID || a.Status != model.AttemptInProgress {
+			continue
+		}
+		submitted := cloneAttempt(attempt)
+		a.Status = submitted.Status
+		a.SubmittedAt = submitted.SubmittedAt
+		a.DurationSeconds = submitted.DurationSeconds
+		a.Result = submitted.Result
+		return nil
+	}
+	return ErrNotFound
+}
+
+// MemoryRevisionStore implements RevisionStore in memory.
+type MemoryRevisionStore struct {
+	mu        sync.RWMutex
+	revisions map[int][]model.QuestionRevision
+}
+
+func NewMemoryRevisionStore() *MemoryRevisionStore {
+	return &MemoryRevisionStore{revisions: map[int][]model.QuestionRevision{}}
+}
+
+func (s *MemoryRevisionStore) Append(ctx context.Context, rev model.QuestionRevision) (model.QuestionRevision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rev.Revision = len(s.revisions[rev.QuestionID]) + 1
+	rev.Question = cloneQuestion(rev.Question)
+	s.revisions[rev.QuestionID] = append(s.revisions[rev.QuestionID], rev)
+	return rev, nil
+}
+
+func (s *MemoryRevisionStore) List(ctx context.Context, questionID int) ([]model.QuestionRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var revisions []model.QuestionRevision
+	for _, rev := range s.revisions[questionID] {
+		rev.Question = cloneQuestion(rev.Question)
+		revisions = append(revisions, rev)
+	}
+	return revisions, nil
+}
+
+func (s *MemoryRevisionStore) Find(ctx context.Context, questionID, revision int) (*model.QuestionRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revisions := s.revisions[questionID]
+	if revision < 1 || revision > len(revisions) {
+		return nil, ErrNotFound
+	}
+	rev := revisions[revision-1]
+	rev.Question = cloneQuestion(rev.Question)
+	return &rev, nil
+}
+
+// MemoryAuditStore implements AuditStore in memory. Entries are kept in the
+// order they were appended; their snapshots are shared, as nothing writes to
+// an entry once logged.
+type MemoryAuditStore struct {
+	mu      sync.RWMutex
+	entries []model.AuditEntry
+}
+
+func NewMemoryAuditStore() *MemoryAuditStore {
+	return &MemoryAuditStore{}
+}
+
+func (s *MemoryAuditStore) Append(ctx context.Context, entry model.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *MemoryAuditStore) Find(ctx context.Context, query AuditQuery) ([]model.AuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var entries []model.AuditEntry
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		if query.Limit > 0 && len(entries) == query.Limit {
+			break
+		}
+		if e := s.entries[i]; matchesAudit(e, query) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func matchesAudit(e model.AuditEntry, query AuditQuery) bool {
+	switch {
+	case query.Actor != "" && e.Actor != query.Actor,
+		query.Action != "" && e.Action != query.Action,
+		query.TargetType != "" && e.TargetType != query.TargetType,
+		query.TargetID != 0 && e.TargetID != query.TargetID,
+		!query.Since.IsZero() && e.At.Before(query.Since),
+		!query.Until.IsZero() && !e.At.Before(query.Until):
+		return false
+	}
+	return true
+}
+
+// cloneAttempt deep-copies the parts of a that callers could mutate.
+func cloneAttempt(a model.Attempt) model.Attempt {
+	snapshot := make([]model.Question, len(a.Snapshot))
+	for i, q := range a.Snapshot {
+		snapshot[i] = cloneQuestion(q)
+	}
+	a.Snapshot = snapshot
+	a.Questions = nil
+	if a.SubmittedAt != nil {
+		submittedAt := *a.SubmittedAt
+		a.SubmittedAt = &submittedAt
+	}
+	if a.Result != nil {
+		result := *a.Result
+		result.Results = append([]model.QuizAnswerResult(nil), result.Results...)
+		a.Result = &result
+	}
+	return a
+}
+
+// cloneContact copies c so callers cannot mutate stored slices.
+func cloneContact(c model.Contact) model.Contact {
+	if c.PreferredChannel != nil {
+		c.PreferredChannel = append([]model.Channel{}, c.PreferredChannel...)
+	}
+	if c.PreferredLanguage != nil {
+		c.PreferredLanguage = append([]string{}, c.PreferredLanguage...)
+	}
+	return c
+}
+
+// cloneQuestion copies q so callers cannot mutate stored slices.
+func cloneQuestion(q model.Question) model.Question {
+	if q.Options != nil {
+		q.Options = append([]string{}, q.Options...)
+	}
+	if q.CorrectAnswers != nil {
+		q.CorrectAnswers = append([]string{}, q.CorrectAnswers...)
+	}
+	if q.AcceptedAnswers != nil {
+		q.AcceptedAnswers = append([]string{}, q.AcceptedAnswers...)
+	}
+	if q.Tags != nil {
+		q.Tags = append([]string{}, q.Tags...)
+	}
+	if q.NumericAnswer != nil {
+		answer := *q.NumericAnswer
+		q.NumericAnswer = &answer
+	}
+	return q
+}
@@ -0,0 +1,12 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/AniketGodambe/mongoapi/store"
+	"github.com/AniketGodambe/mongoapi/store/storetest"
+)
+
+func TestMemoryStores(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Stores { return store.NewMemoryStores() })
+}
@@ -0,0 +1,1125 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sync/atomic"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/config"
+	"github.com/AniketGodambe/mongoapi/language"
+	"github.com/AniketGodambe/mongoapi/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Timeouts for talking to MongoDB. Every store call gets operationTimeout on
+// top of the caller's own deadline; startupTimeout covers connecting and
+// preparing the collections.
+const (
+	operationTimeout = 5 * time.Second
+	startupTimeout   = 30 * time.Second
+)
+
+// withTimeout derives the context for a single database operation.
+func withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, operationTimeout)
+}
+
+// Initialize MongoDB connection. Callers own the client and should
+// disconnect it on exit.
+func InitDB(cfg config.MongoConfig) (*mongo.Database, error) {
+	db, err := Connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := Prepare(context.Background(), db, cfg); err != nil {
+		db.Client().Disconnect(context.Background())
+		return nil, err
+	}
+	return db, nil
+}
+
+// Connect returns the configured database without waiting for MongoDB to
+// answer; the driver connects in the background. It fails only on a bad URI
+// or client options. Callers own the client and should disconnect it on exit.
+func Connect(cfg config.MongoConfig) (*mongo.Database, error) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(cfg.URI))
+	if err != nil {
+		return nil, fmt.Errorf("connecting to MongoDB: %w", err)
+	}
+	return client.Database(cfg.Database), nil
+}
+
+// Prepare pings MongoDB, then creates the indexes and ID counters the stores
+// rely on. It is safe to repeat after a failure, except that a
+// *DuplicateKeysError will not go away until the data is fixed.
+func Prepare(ctx context.Context, db *mongo.Database, cfg config.MongoConfig) error {
+	ctx, cancel := context.WithTimeout(ctx, startupTimeout)
+	defer cancel()
+
+	// Ping to ensure connection is successful
+	if err := db.Client().Ping(ctx, nil); err != nil {
+		return fmt.Errorf("pinging MongoDB: %w", err)
+	}
+
+	slog.Info("Connected to MongoDB", "database", cfg.Database)
+
+	// Enforce uniqueness and make sure the ID counters never hand out taken IDs
+	if err := ensureIndexes(ctx, db, cfg); err != nil {
+		return fmt.Errorf("creating indexes: %w", err)
+	}
+	if err := seedSequences(ctx, db, cfg); err != nil {
+		return fmt.Errorf("seeding ID counters: %w", err)
+	}
+	return nil
+}
+
+// MongoHealth implements HealthChecker for a database the server prepares
+// after it starts listening. It reports ErrNotInitialized until Prepare
+// succeeds, and pings MongoDB after that.
+type MongoHealth struct {
+	db       *mongo.Database
+	cfg      config.MongoConfig
+	prepared atomic.Bool
+}
+
+// NewMongoHealth returns a health check for db, not yet prepared.
+func NewMongoHealth(db *mongo.Database, cfg config.MongoConfig) *MongoHealth {
+	return &MongoHealth{db: db, cfg: cfg}
+}
+
+// Prepare runs the package level Prepare and, once it succeeds, lets Check
+// report the database as initialized.
+func (h *MongoHealth) Prepare(ctx context.Context) error {
+	if err := Prepare(ctx, h.db, h.cfg); err != nil {
+		return err
+	}
+	h.prepared.Store(true)
+	return nil
+}
+
+func (h *MongoHealth) Check(ctx context.Context) error {
+	if !h.prepared.Load() {
+		return ErrNotInitialized
+	}
+	return h.db.Client().Ping(ctx, nil)
+}
+
+// ensureIndexes creates the unique indexes the stores rely on. Contacts are
+// already unique on _id, which MongoDB always indexes.
+func ensureIndexes(ctx context.Context, db *mongo.Database, cfg config.MongoConfig) error {
+	err := createIndexes(ctx, db.Collection(cfg.ContactsCollection), []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "mobile", Value: 1}},
+			Options: options.Index().
+				SetName("mobile_unique").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"mobile": bson.M{"$type": "string"}}),
+		},
+		{Keys: bson.D{{Key: "preferred_channel.channel_name", Value: 1}}, Options: options.Index().SetName("channel_name")},
+		{Keys: bson.D{{Key: "preferred_language", Value: 1}}, Options: options.Index().SetName("preferred_language")},
+		{Keys: bson.D{{Key: "deleted_at", Value: 1}}, Options: options.Index().SetName("deleted_at")},
+	})
+	if err != nil {
+		return fmt.Errorf("contacts: %w", err)
+	}
+
+	err = createIndexes(ctx, db.Collection(cfg.QuestionsCollection), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetName("id_unique").SetUnique(true)},
+		{Keys: bson.D{{Key: "question", Value: 1}}, Options: options.Index().SetName("question_unique").SetUnique(true)},
+		{Keys: bson.D{{Key: "category", Value: 1}, {Key: "difficulty", Value: 1}}, Options: options.Index().SetName("category_difficulty")},
+		{Keys: bson.D{{Key: "tags", Value: 1}}, Options: options.Index().SetName("tags")},
+		{Keys: bson.D{{Key: "deleted_at", Value: 1}}, Options: options.Index().SetName("deleted_at")},
+	})
+	if err != nil {
+		return fmt.Errorf("questions: %w", err)
+	}
+
+	err = createIndexes(ctx, db.Collection(cfg.AttemptsCollection), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetName("id_unique").SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "started_at", Value: -1}}, Options: options.Index().SetName("user_started")},
+	})
+	if err != nil {
+		return fmt.Errorf("attempts: %w", err)
+	}
+
+	err = createIndexes(ctx, db.Collection(cfg.RevisionsCollection), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "question_id", Value: 1}, {Key: "revision", Value: 1}}, Options: options.Index().SetName("question_revision_unique").SetUnique(true)},
+	})
+	if err != nil {
+		return fmt.Errorf("revisions: %w", err)
+	}
+
+	err = createIndexes(ctx, db.Collection(cfg.AuditCollection), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "at", Value: -1}}, Options: options.Index().SetName("at")},
+		{Keys: bson.D{{Key: "actor", Value: 1}, {Key: "at", Value: -1}}, Options: options.Index().SetName("actor_at")},
+		{Keys: bson.D{{Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}, {Key: "at", Value: -1}}, Options: options.Index().SetName("target_at")},
+	})
+	if err != nil {
+		return fmt.Errorf("audit log: %w", err)
+	}
+	return nil
+}
+
+// maxReportedDuplicates caps how many shared keys a DuplicateKeysError names.
+const maxReportedDuplicates = 20
+
+// DuplicateKeysError is returned by Prepare when a unique index cannot be
+// built because stored documents already share a key, as databases written
+// before the index existed may. Retrying cannot help: the documents have to
+// be renumbered or removed first.
+type DuplicateKeysError struct {
+	Collection string
+	Index      string
+	// Keys holds up to maxReportedDuplicates of the shared values.
+	Keys []interface{}
+}
+
+func (e *DuplicateKeysError) Error() string {
+	return fmt.Sprintf("index %s cannot be built: several documents in %s share each of %v; renumber or remove them and restart",
+		e.Index, e.Collection, e.Keys)
+}
+
+// createIndexes builds models on coll. When a unique index fails on existing
+// duplicates, it looks them up and returns a *DuplicateKeysError naming them.
+func createIndexes(ctx context.Context, coll *mongo.Collection, models []mongo.IndexModel) error {
+	_, err := coll.Indexes().CreateMany(ctx, models)
+	if err == nil || !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	for _, m := range models {
+		if m.Options == nil || m.Options.Unique == nil || !*m.Options.Unique {
+			continue
+		}
+		keys, err := duplicateKeys(ctx, coll, m)
+		if err != nil {
+			return fmt.Errorf("listing duplicates for index %s: %w", *m.Options.Name, err)
+		}
+		if len(keys) > 0 {
+			return &DuplicateKeysError{Collection: coll.Name(), Index: *m.Options.Name, Keys: keys}
+		}
+	}
+	return err
+}
+
+// duplicateKeys returns values of the unique index m that more than one
+// document in coll shares. Compound keys come back as documents.
+func duplicateKeys(ctx context.Context, coll *mongo.Collection, m mongo.IndexModel) ([]interface{}, error) {
+	fields := m.Keys.(bson.D)
+	group := bson.D{}
+	for _, f := range fields {
+		group = append(group, bson.E{Key: f.Key, Value: "$" + f.Key})
+	}
+	var id interface{} = group
+	if len(group) == 1 {
+		id = group[0].Value
+	}
+
+	var match interface{} = bson.M{}
+	if m.Options.PartialFilterExpression != nil {
+		match = m.Options.PartialFilterExpression
+	}
+	cursor, err := coll.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.D{{Key: "_id", Value: id}, {Key: "count", Value: bson.M{"$sum": 1}}}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		{{Key: "$limit", Value: maxReportedDuplicates}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		ID interface{} `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	keys := make([]interface{}, 0, len(groups))
+	for _, g := range groups {
+		keys = append(keys, g.ID)
+	}
+	return keys, nil
+}
+
+// seedSequences raises each counter to at least the highest ID already stored,
+// so databases populated before the counters existed keep working.
+func seedSequences(ctx context.Context, db *mongo.Database, cfg config.MongoConfig) error {
+	counters := db.Collection(cfg.CountersCollection)
+	seeds := []struct {
+		name  string
+		coll  string
+		field string
+	}{
+		{ContactsSequence, cfg.ContactsCollection, "_id"},
+		{QuestionsSequence, cfg.QuestionsCollection, "id"},
+		{AttemptsSequence, cfg.AttemptsCollection, "id"},
+	}
+
+	for _, seed := range seeds {
+		var doc bson.M
+		opts := options.FindOne().SetSort(bson.D{{Key: seed.field, Value: -1}})
+		filter := bson.M{seed.field: bson.M{"$type": "number"}}
+		err := db.Collection(seed.coll).FindOne(ctx, filter, opts).Decode(&doc)
+		if err == mongo.ErrNoDocuments {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("%s: %w", seed.name, err)
+		}
+
+		maxID, ok := asInt64(doc[seed.field])
+		if !ok {
+			continue
+		}
+		_, err = counters.UpdateOne(ctx,
+			bson.M{"_id": seed.name},
+			bson.M{"$max": bson.M{"seq": maxID}},
+			options.Update().SetUpsert(true))
+		if err != nil {
+			return fmt.Errorf("%s: %w", seed.name, err)
+		}
+	}
+	return nil
+}
+
+func asInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case float64:
+		return int64(n), true
+	}
+	return 0, false
+}
+
+// NewMongoStores returns stores backed by the configured collections of db.
+func NewMongoStores(db *mongo.Database, cfg config.MongoConfig) Stores {
+	return Stores{
+		Contacts:  &MongoContactStore{coll: instrument(db.Collection(cfg.ContactsCollection))},
+		Questions: &MongoQuestionStore{coll: instrument(db.Collection(cfg.QuestionsCollection))},
+		Attempts:  &MongoAttemptStore{coll: instrument(db.Collection(cfg.AttemptsCollection))},
+		Revisions: &MongoRevisionStore{coll: instrument(db.Collection(cfg.RevisionsCollection))},
+		Audit:     &MongoAuditStore{coll: instrument(db.Collection(cfg.AuditCollection))},
+		IDs:       &MongoSequence{coll: instrument(db.Collection(cfg.CountersCollection))},
+	}
+}
+
+// MongoSequence implements Sequence with one {_id: name, seq: n} document per
+// name, incremented atomically with $inc.
+type MongoSequence struct {
+	coll *collection
+}
+
+func (s *MongoSequence) Next(ctx context.Context, name string) (int, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var counter struct {
+		Seq int `bson:"seq"`
+	}
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	err := s.coll.FindOneAndUpdate(ctx, bson.M{"_id": name}, bson.M{"$inc": bson.M{"seq": 1}}, opts).Decode(&counter)
+	if mongo.IsDuplicateKeyError(err) {
+		// Two first calls raced to upsert the counter; the loser can now increment it
+		err = s.coll.FindOneAndUpdate(ctx, bson.M{"_id": name}, bson.M{"$inc": bson.M{"seq": 1}}, opts).Decode(&counter)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return counter.Seq, nil
+}
+
+// MongoContactStore implements ContactStore on a MongoDB collection.
+type MongoContactStore struct {
+	coll *collection
+}
+
+// contactFilter matches languages stored either as a code or, as in older
+// documents, by English name.
+func contactFilter(query ContactQuery) bson.M {
+	filter := bson.M{"deleted_at": nil}
+	if query.Channel != "" {
+		filter["preferred_channel.channel_name"] = query.Channel
+	}
+	if query.Language != "" {
+		values := bson.A{query.Language}
+		if name, ok := language.Name(query.Language); ok {
+			values = append(values, name)
+		}
+		filter["preferred_language"] = bson.M{"$in": values}
+	}
+	return filter
+}
+
+func (s *MongoContactStore) List(ctx context.Context, query ContactQuery) ([]model.Contact, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var contacts []model.Contact
+	cursor, err := s.coll.Find(ctx, contactFilter(query))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var contact model.Contact
+		if err := cursor.Decode(&contact); err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, contact)
+	}
+
+	return contacts, cursor.Err()
+}
+
+// Each streams the whole collection, so it is bounded only by ctx rather
+// than the per-operation timeout.
+func (s *MongoContactStore) Each(ctx context.Context, fn func(model.Contact) error) error {
+	cursor, err := s.coll.Find(ctx, bson.M{"deleted_at": nil})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var contact model.Contact
+		if err := cursor.Decode(&contact); err != nil {
+			return err
+		}
+		if err := fn(contact); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (s *MongoContactStore) FindByID(ctx context.Context, id int) (*model.Contact, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var contact model.Contact
+	err := s.coll.FindOne(ctx, bson.M{"_id": id, "deleted_at": nil}).Decode(&contact)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+func (s *MongoContactStore) FindByMobile(ctx context.Context, mobile string) (*model.Contact, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var contact model.Contact
+	err := s.coll.FindOne(ctx, bson.M{"mobile": mobile}).Decode(&contact)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+func (s *MongoContactStore) Count(ctx context.Context) (int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	return s.coll.CountDocuments(ctx, bson.M{"deleted_at": nil})
+}
+
+func (s *MongoContactStore) Insert(ctx context.Context, contact model.Contact) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.coll.InsertOne(ctx, contact)
+	return mapWriteError(err)
+}
+
+func (s *MongoContactStore) Patch(ctx context.Context, id int, patch model.ContactPatch) (*model.Contact, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	set := bson.M{}
+	if patch.ContactName != nil {
+		set["contact_name"] = *patch.ContactName
+	}
+	if patch.Age != nil {
+		set["age"] = *patch.Age
+	}
+	if patch.Mobile != nil {
+		set["mobile"] = *patch.Mobile
+	}
+	if patch.PreferredChannel != nil {
+		set["preferred_channel"] = *patch.PreferredChannel
+	}
+	if patch.PreferredLanguage != nil {
+		set["preferred_language"] = *patch.PreferredLanguage
+	}
+
+	var contact model.Contact
+	var err error
+	if len(set) == 0 {
+		err = s.coll.FindOne(ctx, bson.M{"_id": id, "deleted_at": nil}).Decode(&contact)
+	} else {
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		err = s.coll.FindOneAndUpdate(ctx, bson.M{"_id": id, "deleted_at": nil}, bson.M{"$set": set}, opts).Decode(&contact)
+	}
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, mapWriteError(err)
+	}
+	return &contact, nil
+}
+
+func (s *MongoContactStore) Trash(ctx context.Context, id int, at time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.coll.UpdateOne(ctx, bson.M{"_id": id, "deleted_at": nil}, bson.M{"$set": bson.M{"deleted_at": at}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (s *MongoContactStore) TrashAll(ctx context.Context, at time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.coll.UpdateMany(ctx, bson.M{"deleted_at": nil}, bson.M{"$set": bson.M{"deleted_at": at}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (s *MongoContactStore) ListTrash(ctx context.Context) ([]model.Contact, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "deleted_at", Value: -1}})
+	cursor, err := s.coll.Find(ctx, bson.M{"deleted_at": bson.M{"$ne": nil}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var contacts []model.Contact
+	if err := cursor.All(ctx, &contacts); err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+func (s *MongoContactStore) FindTrashed(ctx context.Context, id int) (*model.Contact, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var contact model.Contact
+	err := s.coll.FindOne(ctx, bson.M{"_id": id, "deleted_at": bson.M{"$ne": nil}}).Decode(&contact)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+func (s *MongoContactStore) Restore(ctx context.Context, id int) (int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.coll.UpdateOne(ctx, bson.M{"_id": id, "deleted_at": bson.M{"$ne": nil}}, bson.M{"$unset": bson.M{"deleted_at": ""}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (s *MongoContactStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.coll.DeleteMany(ctx, bson.M{"deleted_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// MongoQuestionStore implements QuestionStore on a MongoDB collection.
+type MongoQuestionStore struct {
+	coll *collection
+}
+
+func (s *MongoQuestionStore) List(ctx context.Context) ([]model.Question, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var questions []model.Question
+	cursor, err := s.coll.Find(ctx, bson.M{"deleted_at": nil})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &questions); err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
+
+func questionFilter(query QuestionQuery) bson.M {
+	filter := bson.M{"deleted_at": nil}
+	if query.Hidden != nil {
+		filter["hidden"] = *query.Hidden
+	}
+	if query.Search != "" {
+		filter["question"] = bson.M{"$regex": regexp.QuoteMeta(query.Search), "$options": "i"}
+	}
+	if query.Category != "" {
+		filter["category"] = query.Category
+	}
+	if len(query.Tags) > 0 {
+		filter["tags"] = bson.M{"$all": query.Tags}
+	}
+	if query.Difficulty != "" {
+		filter["difficulty"] = query.Difficulty
+	}
+	return filter
+}
+
+func (s *MongoQuestionStore) Find(ctx context.Context, query QuestionQuery) (QuestionPage, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	filter := questionFilter(query)
+
+	total, err := s.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return QuestionPage{}, err
+	}
+
+	field := query.SortBy
+	if field == "" {
+		field = SortByID
+	}
+	dir, cmp := 1, "$gt"
+	if query.Desc {
+		dir, cmp = -1, "$lt"
+	}
+
+	if after := query.After; after != nil {
+		var position bson.M
+		if field == SortByID {
+			position = bson.M{"id": bson.M{cmp: after.ID}}
+		} else {
+			position = bson.M{"$or": bson.A{
+				bson.M{field: bson.M{cmp: after.Time}},
+				bson.M{field: after.Time, "id": bson.M{cmp: after.ID}},
+			}}
+		}
+		filter = bson.M{"$and": bson.A{filter, position}}
+	}
+
+	sort := bson.D{{Key: field, Value: dir}}
+	if field != SortByID {
+		sort = append(sort, bson.E{Key: "id", Value: dir})
+	}
+	opts := options.Find().SetSort(sort).SetSkip(int64(query.Skip))
+	if query.Limit > 0 {
+		// Fetch one extra document to learn whether another page exists
+		opts.SetLimit(int64(query.Limit) + 1)
+	}
+
+	cursor, err := s.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return QuestionPage{}, err
+	}
+	defer cursor.Close(ctx)
+
+	var questions []model.Question
+	if err := cursor.All(ctx, &questions); err != nil {
+		return QuestionPage{}, err
+	}
+
+	page := QuestionPage{Questions: questions, Total: total}
+	if query.Limit > 0 && len(questions) > query.Limit {
+		page.Questions = questions[:query.Limit]
+		page.HasMore = true
+	}
+	return page, nil
+}
+
+func (s *MongoQuestionStore) FindByID(ctx context.Context, id int) (*model.Question, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var question model.Question
+	err := s.coll.FindOne(ctx, bson.M{"id": id, "deleted_at": nil}).Decode(&question)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &question, nil
+}
+
+func (s *MongoQuestionStore) FindByObjectID(ctx context.Context, id primitive.ObjectID) (*model.Question, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var question model.Question
+	err := s.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&question)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &question, nil
+}
+
+func (s *MongoQuestionStore) Sample(ctx context.Context, n int) ([]model.Question, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"hidden": false, "deleted_at": nil}}},
+		{{Key: "$sample", Value: bson.M{"size": n}}},
+	}
+	cursor, err := s.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var questions []model.Question
+	if err := cursor.All(ctx, &questions); err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
+
+func (s *MongoQuestionStore) ExistsByText(ctx context.Context, text string, excludeID int) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	filter := bson.M{
+		"question": text,
+		"id":       bson.M{"$ne": excludeID},
+	}
+	count, err := s.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (s *MongoQuestionStore) Count(ctx context.Context) (int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	return s.coll.CountDocuments(ctx, bson.M{"deleted_at": nil})
+}
+
+func (s *MongoQuestionStore) CountByVisibility(ctx context.Context) (int64, int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	cursor, err := s.coll.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"deleted_at": nil}}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"$eq": bson.A{"$hidden", true}}, "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		Hidden bool  `bson:"_id"`
+		Count  int64 `bson:"count"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return 0, 0, err
+	}
+	var visible, hidden int64
+	for _, g := range groups {
+		if g.Hidden {
+			hidden = g.Count
+		} else {
+			visible = g.Count
+		}
+	}
+	return visible, hidden, nil
+}
+
+func (s *MongoQuestionStore) Categories(ctx context.Context) ([]model.TermCount, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	return s.countTerms(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"deleted_at": nil, "category": bson.M{"$nin": bson.A{nil, ""}}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$category", "count": bson.M{"$sum": 1}}}},
+	})
+}
+
+func (s *MongoQuestionStore) Tags(ctx context.Context) ([]model.TermCount, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	return s.countTerms(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"deleted_at": nil}}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+	})
+}
+
+// countTerms runs a grouping pipeline and orders the counts, most used first.
+func (s *MongoQuestionStore) countTerms(ctx context.Context, pipeline mongo.Pipeline) ([]model.TermCount, error) {
+	pipeline = append(pipeline, bson.D{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}})
+	cursor, err := s.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	counts := []model.TermCount{}
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (s *MongoQuestionStore) Insert(ctx context.Context, question model.Question) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.coll.InsertOne(ctx, question)
+	return mapWriteError(err)
+}
+
+func (s *MongoQuestionStore) Update(ctx context.Context, question model.Question) (int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"type":             question.Type,
+			"question":         question.Question,
+			"options":          question.Options,
+			"correct_answer":   question.CorrectAns,
+			"correct_answers":  question.CorrectAnswers,
+			"numeric_answer":   question.NumericAnswer,
+			"tolerance":        question.Tolerance,
+			"accepted_answers": question.AcceptedAnswers,
+			"answer_pattern":   question.AnswerPattern,
+			"case_sensitive":   question.CaseSensitive,
+			"reason":           question.Reason,
+			"category":         question.Category,
+			"tags":             question.Tags,
+			"difficulty":       question.Difficulty,
+			"hidden":           question.Hidden,
+			"last_modified":    question.LastModified,
+		},
+	}
+
+	result, err := s.coll.UpdateOne(ctx, bson.M{"id": question.ID, "deleted_at": nil}, update)
+	if err != nil {
+		return 0, mapWriteError(err)
+	}
+	return result.MatchedCount, nil
+}
+
+func (s *MongoQuestionStore) SetHidden(ctx context.Context, id int, hidden bool) (int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"hidden":        hidden,
+			"last_modified": time.Now(),
+		},
+	}
+
+	result, err := s.coll.UpdateOne(ctx, bson.M{"id": id, "deleted_at": nil}, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.MatchedCount, nil
+}
+
+func (s *MongoQuestionStore) Trash(ctx context.Context, id int, at time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.coll.UpdateOne(ctx, bson.M{"id": id, "deleted_at": nil}, bson.M{"$set": bson.M{"deleted_at": at}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (s *MongoQuestionStore) ListTrash(ctx context.Context) ([]model.Question, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "deleted_at", Value: -1}})
+	cursor, err := s.coll.Find(ctx, bson.M{"deleted_at": bson.M{"$ne": nil}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var questions []model.Question
+	if err := cursor.All(ctx, &questions); err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
+
+func (s *MongoQuestionStore) FindTrashed(ctx context.Context, id int) (*model.Question, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var question model.Question
+	err := s.coll.FindOne(ctx, bson.M{"id": id, "deleted_at": bson.M{"$ne": nil}}).Decode(&question)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &question, nil
+}
+
+func (s *MongoQuestionStore) Restore(ctx context.Context, id int) (int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.coll.UpdateOne(ctx, bson.M{"id": id, "deleted_at": bson.M{"$ne": nil}}, bson.M{"$unset": bson.M{"deleted_at": ""}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (s *MongoQuestionStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.coll.DeleteMany(ctx, bson.M{"deleted_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// MongoAttemptStore implements AttemptStore on a MongoDB collection.
+type MongoAttemptStore struct {
+	coll *collection
+}
+
+func (s *MongoAttemptStore) Insert(ctx context.Context, attempt model.Attempt) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.coll.InsertOne(ctx, attempt)
+	return mapWriteError(err)
+}
+
+func (s *MongoAttemptStore) FindByID(ctx context.Context, id int) (*model.Attempt, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var attempt model.Attempt
+	err := s.coll.FindOne(ctx, bson.M{"id": id}).Decode(&attempt)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+func (s *MongoAttemptStore) ListByUser(ctx context.Context, userID string) ([]model.Attempt, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}, {Key: "id", Value: -1}})
+	cursor, err := s.coll.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var attempts []model.Attempt
+	if err := cursor.All(ctx, &attempts); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+func (s *MongoAttemptStore) Submit(ctx context.Context, attempt model.Attempt) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	filter := bson.M{"id": attempt.ID, "status": model.AttemptInProgress}
+	update := bson.M{
+		"$set": bson.M{
+			"status":           attempt.Status,
+			"submitted_at":     attempt.SubmittedAt,
+			"duration_seconds": attempt.DurationSeconds,
+			"result":           attempt.Result,
+		},
+	}
+
+	result, err := s.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MongoRevisionStore implements RevisionStore on a MongoDB collection.
+type MongoRevisionStore struct {
+	coll *collection
+}
+
+// Append numbers the revision after the latest one. The unique index on
+// (question_id, revision) catches concurrent writers, which then retry.
+func (s *MongoRevisionStore) Append(ctx context.Context, rev model.QuestionRevision) (model.QuestionRevision, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	for attempt := 0; ; attempt++ {
+		var latest model.QuestionRevision
+		opts := options.FindOne().SetSort(bson.D{{Key: "revision", Value: -1}}).SetProjection(bson.M{"revision": 1})
+		err := s.coll.FindOne(ctx, bson.M{"question_id": rev.QuestionID}, opts).Decode(&latest)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return rev, err
+		}
+
+		rev.Revision = latest.Revision + 1
+		_, err = s.coll.InsertOne(ctx, rev)
+		if err = mapWriteError(err); err != ErrDuplicate || attempt == 2 {
+			return rev, err
+		}
+	}
+}
+
+func (s *MongoRevisionStore) List(ctx context.Context, questionID int) ([]model.QuestionRevision, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "revision", Value: 1}})
+	cursor, err := s.coll.Find(ctx, bson.M{"question_id": questionID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var revisions []model.QuestionRevision
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (s *MongoRevisionStore) Find(ctx context.Context, questionID, revision int) (*model.QuestionRevision, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var rev model.QuestionRevision
+	err := s.coll.FindOne(ctx, bson.M{"question_id": questionID, "revision": revision}).Decode(&rev)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// MongoAuditStore implements AuditStore on a MongoDB collection it only ever
+// inserts into.
+type MongoAuditStore struct {
+	coll *collection
+}
+
+func (s *MongoAuditStore) Append(ctx context.Context, entry model.AuditEntry) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.coll.InsertOne(ctx, entry)
+	return err
+}
+
+func (s *MongoAuditStore) Find(ctx context.Context, query AuditQuery) ([]model.AuditEntry, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	filter := bson.M{}
+	if query.Actor != "" {
+		filter["actor"] = query.Actor
+	}
+	if query.Action != "" {
+		filter["action"] = query.Action
+	}
+	if query.TargetType != "" {
+		filter["target_type"] = query.TargetType
+	}
+	if query.TargetID != 0 {
+		filter["target_id"] = query.TargetID
+	}
+	at := bson.M{}
+	if !query.Since.IsZero() {
+		at["$gte"] = query.Since
+	}
+	if !query.Until.IsZero() {
+		at["$lt"] = query.Until
+	}
+	if len(at) > 0 {
+		filter["at"] = at
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: -1}, {Key: "_id", Value: -1}})
+	if query.Limit > 0 {
+		opts.SetLimit(int64(query.Limit))
+	}
+	cursor, err := s.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []model.AuditEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// mapWriteError translates unique index violations into ErrDuplicate.
+func mapWriteError(err error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicate
+	}
+	return err
+}
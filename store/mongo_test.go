@@ -0,0 +1,134 @@
+package store_test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/AniketGodambe/mongoapi/config"
+	"github.com/AniketGodambe/mongoapi/store"
+	"github.com/AniketGodambe/mongoapi/store/storetest"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// testMongoURIEnv names the MongoDB server the Mongo store tests run
+// against. They are skipped when it is unset. Every test works in its own
+// throwaway database, dropped afterwards.
+const testMongoURIEnv = "MONGOAPI_TEST_MONGO_URI"
+
+// connectTestMongo skips t unless a test server is configured, and returns
+// the configuration of a fresh database on it that is dropped when t ends.
+func connectTestMongo(t *testing.T) (*mongo.Database, config.MongoConfig) {
+	t.Helper()
+	uri := os.Getenv(testMongoURIEnv)
+	if uri == "" {
+		t.Skipf("set %s to run the MongoDB store tests", testMongoURIEnv)
+	}
+
+	suffix := make([]byte, 6)
+	rand.Read(suffix)
+	cfg := config.Default().Mongo
+	cfg.URI = uri
+	cfg.Database = "mongoapi_test_" + hex.EncodeToString(suffix)
+
+	db, err := store.Connect(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		ctx := context.Background()
+		if err := db.Drop(ctx); err != nil {
+			t.Errorf("dropping %s: %v", cfg.Database, err)
+		}
+		db.Client().Disconnect(ctx)
+	})
+	return db, cfg
+}
+
+func TestMongoStores(t *testing.T) {
+	if os.Getenv(testMongoURIEnv) == "" {
+		t.Skipf("set %s to run the MongoDB store tests", testMongoURIEnv)
+	}
+	storetest.Run(t, func(t *testing.T) store.Stores {
+		db, cfg := connectTestMongo(t)
+		if err := store.Prepare(t.Context(), db, cfg); err != nil {
+			t.Fatal(err)
+		}
+		return store.NewMongoStores(db, cfg)
+	})
+}
+
+// TestMongoSeedsSequences checks that Prepare raises the ID counters past
+// documents written before the counters existed.
+func TestMongoSeedsSequences(t *testing.T) {
+	db, cfg := connectTestMongo(t)
+	ctx := t.Context()
+	if _, err := db.Collection(cfg.ContactsCollection).InsertOne(ctx, bson.M{"_id": 41, "contact_name": "Legacy"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Collection(cfg.QuestionsCollection).InsertOne(ctx, bson.M{"id": int64(7), "question": "Legacy?"}); err != nil {
+		t.Fatal(err)
+	}
+	// A counter already ahead of the data must not be lowered
+	if _, err := db.Collection(cfg.CountersCollection).InsertOne(ctx, bson.M{"_id": store.AttemptsSequence, "seq": 90}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Collection(cfg.AttemptsCollection).InsertOne(ctx, bson.M{"id": 12}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Prepare(ctx, db, cfg); err != nil {
+		t.Fatal(err)
+	}
+	// Preparing again, as after a restart, changes nothing
+	if err := store.Prepare(ctx, db, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := store.NewMongoStores(db, cfg).IDs
+	for name, want := range map[string]int{
+		store.ContactsSequence:  42,
+		store.QuestionsSequence: 8,
+		store.AttemptsSequence:  91,
+	} {
+		got, err := ids.Next(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Next(%q) = %d, want %d", name, got, want)
+		}
+	}
+}
+
+// TestMongoReportsDuplicateKeys checks that Prepare names the stored
+// duplicates that keep a unique index from being built.
+func TestMongoReportsDuplicateKeys(t *testing.T) {
+	db, cfg := connectTestMongo(t)
+	ctx := t.Context()
+	contacts := db.Collection(cfg.ContactsCollection)
+	for i, mobile := range []interface{}{"9876543210", "9876543210", "9123456780", nil, nil} {
+		doc := bson.M{"_id": i + 1, "contact_name": "Dup"}
+		if mobile != nil {
+			doc["mobile"] = mobile
+		}
+		if _, err := contacts.InsertOne(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := store.Prepare(ctx, db, cfg)
+	var dupErr *store.DuplicateKeysError
+	if !errors.As(err, &dupErr) {
+		t.Fatalf("Prepare = %v, want a *DuplicateKeysError", err)
+	}
+	// Contacts without a mobile fall outside the partial index
+	if dupErr.Collection != cfg.ContactsCollection || dupErr.Index != "mobile_unique" ||
+		len(dupErr.Keys) != 1 || dupErr.Keys[0] != "9876543210" {
+		t.Errorf("DuplicateKeysError = %+v, want mobile 9876543210 only", dupErr)
+	}
+}
@@ -0,0 +1,268 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrNotFound is returned when no document matches the lookup.
+var ErrNotFound = errors.New("store: document not found")
+
+// ErrDuplicate is returned when a write would break a unique constraint.
+var ErrDuplicate = errors.New("store: duplicate key")
+
+// ErrInvalidRef is returned by ParseRef for a malformed identifier.
+var ErrInvalidRef = errors.New("store: invalid identifier")
+
+// ErrNotInitialized is returned by health checks while the database is still
+// being prepared.
+var ErrNotInitialized = errors.New("store: collections not initialized")
+
+// Ref identifies a contact or question as sent by a client: the public
+// integer ID handed out on create, or the Mongo ObjectID as a fallback for
+// scripts that read documents straight from the database. Contacts are
+// stored under their public ID and have no ObjectID. Exactly one field is
+// set.
+type Ref struct {
+	ID  int
+	OID primitive.ObjectID
+}
+
+// ParseRef reads s as a public ID first and as an ObjectID hex second.
+func ParseRef(s string) (Ref, error) {
+	if id, err := strconv.Atoi(s); err == nil {
+		if id <= 0 {
+			return Ref{}, ErrInvalidRef
+		}
+		return Ref{ID: id}, nil
+	}
+	oid, err := primitive.ObjectIDFromHex(s)
+	if err != nil {
+		return Ref{}, ErrInvalidRef
+	}
+	return Ref{OID: oid}, nil
+}
+
+// IsObjectID reports whether the ref holds an ObjectID rather than a public ID.
+func (r Ref) IsObjectID() bool {
+	return !r.OID.IsZero()
+}
+
+// Sequence names used for public IDs.
+const (
+	ContactsSequence  = "contacts"
+	QuestionsSequence = "questions"
+	AttemptsSequence  = "attempts"
+)
+
+// Sequence hands out collision-free, never reused IDs. Each name counts
+// independently, starting at 1.
+type Sequence interface {
+	Next(ctx context.Context, name string) (int, error)
+}
+
+// ContactQuery filters a contact listing. Empty fields match everything.
+type ContactQuery struct {
+	// Channel is a channel type such as model.ChannelEmail.
+	Channel string
+	// Language is an ISO 639-1 code.
+	Language string
+}
+
+// ContactStore is the persistence contract for contacts. Deleting a contact
+// moves it to the trash by setting DeletedAt; trashed contacts are left out
+// of every read unless noted, and stay there until restored or purged.
+type ContactStore interface {
+	List(ctx context.Context, query ContactQuery) ([]model.Contact, error)
+	// Each calls fn for every contact in storage order, stopping at the first error.
+	Each(ctx context.Context, fn func(model.Contact) error) error
+	FindByID(ctx context.Context, id int) (*model.Contact, error)
+	// FindByMobile includes trashed contacts, which still own their mobile.
+	FindByMobile(ctx context.Context, mobile string) (*model.Contact, error)
+	Count(ctx context.Context) (int64, error)
+	// Insert returns ErrDuplicate when the ID or mobile is already taken.
+	Insert(ctx context.Context, contact model.Contact) error
+	// Patch applies the set fields of patch and returns the updated contact.
+	// It returns ErrNotFound for an unknown ID and ErrDuplicate when the new
+	// mobile belongs to another contact.
+	Patch(ctx context.Context, id int, patch model.ContactPatch) (*model.Contact, error)
+	// Trash and TrashAll soft delete, stamping DeletedAt with at.
+	Trash(ctx context.Context, id int, at time.Time) (int64, error)
+	TrashAll(ctx context.Context, at time.Time) (int64, error)
+	// ListTrash returns trashed contacts, most recently deleted first.
+	ListTrash(ctx context.Context) ([]model.Contact, error)
+	// FindTrashed returns one trashed contact, or ErrNotFound when the ID is
+	// unknown or not in the trash.
+	FindTrashed(ctx context.Context, id int) (*model.Contact, error)
+	Restore(ctx context.Context, id int) (int64, error)
+	// Purge permanently removes contacts trashed before the cutoff.
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Sortable question fields.
+const (
+	SortByID           = "id"
+	SortByCreatedAt    = "created_at"
+	SortByLastModified = "last_modified"
+)
+
+// QuestionQuery filters, orders and pages a question listing. The zero value
+// lists everything by ascending ID.
+type QuestionQuery struct {
+	Hidden *bool
+	// Search matches question text case-insensitively.
+	Search   string
+	Category string
+	// Tags matches questions carrying every listed tag.
+	Tags       []string
+	Difficulty string
+	SortBy     string
+	Desc       bool
+	// Limit caps the page size; 0 means no limit.
+	Limit int
+	// Skip and After are alternatives: an offset or a keyset position.
+	Skip  int
+	After *QuestionCursor
+}
+
+// QuestionCursor is the sort key of the last question on a page. Time holds
+// the sort field when sorting by a timestamp; ID always breaks ties.
+type QuestionCursor struct {
+	ID   int       `json:"id"`
+	Time time.Time `json:"t,omitzero"`
+}
+
+// CursorFor returns the cursor positioned right after q for the query's sort.
+func (query QuestionQuery) CursorFor(q model.Question) *QuestionCursor {
+	cursor := &QuestionCursor{ID: q.ID}
+	switch query.SortBy {
+	case SortByCreatedAt:
+		cursor.Time = q.CreatedAt
+	case SortByLastModified:
+		cursor.Time = q.LastModified
+	}
+	return cursor
+}
+
+// QuestionPage is one page of a QuestionQuery.
+type QuestionPage struct {
+	Questions []model.Question
+	// Total counts every question matching the filters, across all pages.
+	Total   int64
+	HasMore bool
+}
+
+// QuestionStore is the persistence contract for questions. Deletes are soft,
+// as for contacts.
+type QuestionStore interface {
+	List(ctx context.Context) ([]model.Question, error)
+	Find(ctx context.Context, query QuestionQuery) (QuestionPage, error)
+	FindByID(ctx context.Context, id int) (*model.Question, error)
+	// FindByObjectID includes trashed questions.
+	FindByObjectID(ctx context.Context, id primitive.ObjectID) (*model.Question, error)
+	// Sample returns up to n visible questions picked at random.
+	Sample(ctx context.Context, n int) ([]model.Question, error)
+	// ExistsByText reports whether another question (ignoring excludeID) uses
+	// the text, trashed ones included.
+	ExistsByText(ctx context.Context, text string, excludeID int) (bool, error)
+	Count(ctx context.Context) (int64, error)
+	// CountByVisibility counts the questions not in the trash that are
+	// visible and hidden, in a single query.
+	CountByVisibility(ctx context.Context) (visible, hidden int64, err error)
+	// Categories and Tags count the questions using each value, most used first.
+	Categories(ctx context.Context) ([]model.TermCount, error)
+	Tags(ctx context.Context) ([]model.TermCount, error)
+	// Insert returns ErrDuplicate when the ID or text is already taken.
+	Insert(ctx context.Context, question model.Question) error
+	// Update overwrites the editable fields of the question with the same ID.
+	Update(ctx context.Context, question model.Question) (int64, error)
+	SetHidden(ctx context.Context, id int, hidden bool) (int64, error)
+	Trash(ctx context.Context, id int, at time.Time) (int64, error)
+	// ListTrash returns trashed questions, most recently deleted first.
+	ListTrash(ctx context.Context) ([]model.Question, error)
+	// FindTrashed returns one trashed question, like ContactStore.FindTrashed.
+	FindTrashed(ctx context.Context, id int) (*model.Question, error)
+	Restore(ctx context.Context, id int) (int64, error)
+	// Purge permanently removes questions trashed before the cutoff.
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// AttemptStore is the persistence contract for quiz attempts.
+type AttemptStore interface {
+	Insert(ctx context.Context, attempt model.Attempt) error
+	FindByID(ctx context.Context, id int) (*model.Attempt, error)
+	// ListByUser returns the user's attempts, newest first.
+	ListByUser(ctx context.Context, userID string) ([]model.Attempt, error)
+	// Submit records the outcome of an in-progress attempt. It returns
+	// ErrNotFound when no attempt with that ID is still in progress.
+	Submit(ctx context.Context, attempt model.Attempt) error
+}
+
+// RevisionStore keeps the revision history of questions.
+type RevisionStore interface {
+	// Append stores rev under the next revision number of its question and
+	// returns it with Revision set.
+	Append(ctx context.Context, rev model.QuestionRevision) (model.QuestionRevision, error)
+	// List returns the revisions of a question, oldest first.
+	List(ctx context.Context, questionID int) ([]model.QuestionRevision, error)
+	Find(ctx context.Context, questionID, revision int) (*model.QuestionRevision, error)
+}
+
+// AuditQuery filters the audit log. Zero fields match everything.
+type AuditQuery struct {
+	Actor      string
+	Action     string
+	TargetType string
+	TargetID   int
+	// Since and Until bound the entry time, Since inclusive.
+	Since time.Time
+	Until time.Time
+	Limit int
+}
+
+// AuditStore is an append-only log of changes; entries are never updated
+// or removed.
+type AuditStore interface {
+	Append(ctx context.Context, entry model.AuditEntry) error
+	// Find returns matching entries, newest first.
+	Find(ctx context.Context, query AuditQuery) ([]model.AuditEntry, error)
+}
+
+// HealthChecker reports whether the database behind the stores can serve
+// requests.
+type HealthChecker interface {
+	// Check returns nil when the database answers before ctx is done,
+	// ErrNotInitialized while its collections are being prepared, or the
+	// error reaching it failed with.
+	Check(ctx context.Context) error
+}
+
+// PurgeTrash permanently removes contacts and questions that have been in
+// the trash for longer than retention.
+func PurgeTrash(ctx context.Context, stores Stores, retention time.Duration) (contacts, questions int64, err error) {
+	before := time.Now().Add(-retention)
+	if contacts, err = stores.Contacts.Purge(ctx, before); err != nil {
+		return 0, 0, err
+	}
+	if questions, err = stores.Questions.Purge(ctx, before); err != nil {
+		return contacts, 0, err
+	}
+	return contacts, questions, nil
+}
+
+// Stores bundles the repositories the API is served from.
+type Stores struct {
+	Contacts  ContactStore
+	Questions QuestionStore
+	Attempts  AttemptStore
+	Revisions RevisionStore
+	Audit     AuditStore
+	IDs       Sequence
+	// Health is optional. Without it the stores are always reported ready.
+	Health HealthChecker
+}
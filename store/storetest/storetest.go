@@ -0,0 +1,683 @@
+// Package storetest checks that an implementation of the store interfaces
+// keeps their contract. Each Run function takes a constructor that returns
+// empty stores, called once per subtest, so the same suite can run against
+// memory and against a real database.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/AniketGodambe/mongoapi/model"
+	"github.com/AniketGodambe/mongoapi/store"
+)
+
+// Run runs every contract in this package against the stores from newStores.
+func Run(t *testing.T, newStores func(t *testing.T) store.Stores) {
+	t.Run("Sequence", func(t *testing.T) { RunSequence(t, func(t *testing.T) store.Sequence { return newStores(t).IDs }) })
+	t.Run("ContactStore", func(t *testing.T) {
+		RunContactStore(t, func(t *testing.T) store.ContactStore { return newStores(t).Contacts })
+	})
+	t.Run("QuestionStore", func(t *testing.T) {
+		RunQuestionStore(t, func(t *testing.T) store.QuestionStore { return newStores(t).Questions })
+	})
+	t.Run("AttemptStore", func(t *testing.T) {
+		RunAttemptStore(t, func(t *testing.T) store.AttemptStore { return newStores(t).Attempts })
+	})
+	t.Run("RevisionStore", func(t *testing.T) {
+		RunRevisionStore(t, func(t *testing.T) store.RevisionStore { return newStores(t).Revisions })
+	})
+	t.Run("AuditStore", func(t *testing.T) {
+		RunAuditStore(t, func(t *testing.T) store.AuditStore { return newStores(t).Audit })
+	})
+}
+
+// base is a fixed point in time; stores may round to milliseconds, so every
+// timestamp used here is whole seconds.
+var base = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func wantErr(t *testing.T, got, want error) {
+	t.Helper()
+	if !errors.Is(got, want) {
+		t.Fatalf("got error %v, want %v", got, want)
+	}
+}
+
+func wantCount(t *testing.T, what string, got int64, err error, want int64) {
+	t.Helper()
+	must(t, err)
+	if got != want {
+		t.Fatalf("%s = %d, want %d", what, got, want)
+	}
+}
+
+// RunSequence checks that IDs start at 1, never repeat and count each name
+// on its own.
+func RunSequence(t *testing.T, newSequence func(t *testing.T) store.Sequence) {
+	ctx := context.Background()
+	ids := newSequence(t)
+
+	for want := 1; want <= 3; want++ {
+		got, err := ids.Next(ctx, store.ContactsSequence)
+		must(t, err)
+		if got != want {
+			t.Fatalf("Next(contacts) = %d, want %d", got, want)
+		}
+	}
+	got, err := ids.Next(ctx, store.QuestionsSequence)
+	must(t, err)
+	if got != 1 {
+		t.Fatalf("Next(questions) = %d after three contacts, want 1", got)
+	}
+}
+
+func contact(id int, mobile string) model.Contact {
+	return model.Contact{
+		ID:          id,
+		ContactName: fmt.Sprintf("Contact %d", id),
+		Age:         30 + id,
+		Mobile:      mobile,
+		PreferredChannel: []model.Channel{
+			{ID: 0, ChannelName: model.ChannelEmail, ChannelDetails: fmt.Sprintf("c%d@example.com", id)},
+		},
+		PreferredLanguage: []string{"en"},
+	}
+}
+
+// RunContactStore checks the contact contract.
+func RunContactStore(t *testing.T, newStore func(t *testing.T) store.ContactStore) {
+	ctx := context.Background()
+
+	t.Run("InsertAndFind", func(t *testing.T) {
+		s := newStore(t)
+		want := contact(1, "9000000001")
+		must(t, s.Insert(ctx, want))
+
+		got, err := s.FindByID(ctx, 1)
+		must(t, err)
+		if got.ContactName != want.ContactName || got.Mobile != want.Mobile || got.Age != want.Age ||
+			len(got.PreferredChannel) != 1 || got.PreferredChannel[0] != want.PreferredChannel[0] {
+			t.Fatalf("FindByID = %+v, want %+v", got, want)
+		}
+		got, err = s.FindByMobile(ctx, want.Mobile)
+		must(t, err)
+		if got.ID != 1 {
+			t.Fatalf("FindByMobile found contact %d, want 1", got.ID)
+		}
+
+		_, err = s.FindByID(ctx, 2)
+		wantErr(t, err, store.ErrNotFound)
+		_, err = s.FindByMobile(ctx, "9000000002")
+		wantErr(t, err, store.ErrNotFound)
+	})
+
+	t.Run("ReadsAreCopies", func(t *testing.T) {
+		s := newStore(t)
+		must(t, s.Insert(ctx, contact(1, "9000000001")))
+		got, err := s.FindByID(ctx, 1)
+		must(t, err)
+		got.PreferredChannel[0].ChannelDetails = "changed"
+		got.PreferredLanguage[0] = "hi"
+
+		again, err := s.FindByID(ctx, 1)
+		must(t, err)
+		if again.PreferredChannel[0].ChannelDetails == "changed" || again.PreferredLanguage[0] == "hi" {
+			t.Fatal("changing a returned contact changed the stored one")
+		}
+	})
+
+	t.Run("InsertDuplicate", func(t *testing.T) {
+		s := newStore(t)
+		must(t, s.Insert(ctx, contact(1, "9000000001")))
+		wantErr(t, s.Insert(ctx, contact(1, "9000000002")), store.ErrDuplicate)
+		wantErr(t, s.Insert(ctx, contact(2, "9000000001")), store.ErrDuplicate)
+
+		// A trashed contact still owns its mobile
+		_, err := s.Trash(ctx, 1, base)
+		must(t, err)
+		wantErr(t, s.Insert(ctx, contact(3, "9000000001")), store.ErrDuplicate)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		s := newStore(t)
+		sms := contact(1, "9000000001")
+		sms.PreferredChannel = []model.Channel{{ChannelName: model.ChannelSMS, ChannelDetails: "9000000001"}}
+		sms.PreferredLanguage = []string{"hi"}
+		must(t, s.Insert(ctx, sms))
+		must(t, s.Insert(ctx, contact(2, "9000000002")))
+		must(t, s.Insert(ctx, contact(3, "9000000003")))
+		_, err := s.Trash(ctx, 3, base)
+		must(t, err)
+
+		for _, tc := range []struct {
+			query store.ContactQuery
+			want  []int
+		}{
+			{store.ContactQuery{}, []int{1, 2}},
+			{store.ContactQuery{Channel: model.ChannelSMS}, []int{1}},
+			{store.ContactQuery{Language: "en"}, []int{2}},
+			{store.ContactQuery{Channel: model.ChannelSMS, Language: "en"}, nil},
+		} {
+			contacts, err := s.List(ctx, tc.query)
+			must(t, err)
+			if got := contactIDs(contacts); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("List(%+v) = %v, want %v", tc.query, got, tc.want)
+			}
+		}
+
+		n, err := s.Count(ctx)
+		wantCount(t, "Count", n, err, 2)
+
+		var each []int
+		must(t, s.Each(ctx, func(c model.Contact) error {
+			each = append(each, c.ID)
+			return nil
+		}))
+		if fmt.Sprint(each) != "[1 2]" {
+			t.Errorf("Each visited %v, want [1 2]", each)
+		}
+
+		stop := errors.New("stop")
+		visited := 0
+		err = s.Each(ctx, func(model.Contact) error {
+			visited++
+			return stop
+		})
+		if !errors.Is(err, stop) || visited != 1 {
+			t.Errorf("Each returned %v after %d calls, want the callback's error after 1", err, visited)
+		}
+	})
+
+	t.Run("Patch", func(t *testing.T) {
+		s := newStore(t)
+		must(t, s.Insert(ctx, contact(1, "9000000001")))
+		must(t, s.Insert(ctx, contact(2, "9000000002")))
+
+		name, age := "Renamed", 0
+		got, err := s.Patch(ctx, 1, model.ContactPatch{ContactName: &name, Age: &age})
+		must(t, err)
+		if got.ContactName != name || got.Age != 0 || got.Mobile != "9000000001" {
+			t.Fatalf("Patch returned %+v", got)
+		}
+		stored, err := s.FindByID(ctx, 1)
+		must(t, err)
+		if stored.ContactName != name || stored.Age != 0 {
+			t.Fatalf("Patch stored %+v", stored)
+		}
+
+		taken := "9000000002"
+		_, err = s.Patch(ctx, 1, model.ContactPatch{Mobile: &taken})
+		wantErr(t, err, store.ErrDuplicate)
+		_, err = s.Patch(ctx, 9, model.ContactPatch{ContactName: &name})
+		wantErr(t, err, store.ErrNotFound)
+
+		// Keeping its own mobile is not a conflict
+		own := "9000000001"
+		_, err = s.Patch(ctx, 1, model.ContactPatch{Mobile: &own})
+		must(t, err)
+	})
+
+	t.Run("TrashAndRestore", func(t *testing.T) {
+		s := newStore(t)
+		must(t, s.Insert(ctx, contact(1, "9000000001")))
+		must(t, s.Insert(ctx, contact(2, "9000000002")))
+
+		n, err := s.Trash(ctx, 1, base)
+		wantCount(t, "Trash", n, err, 1)
+		n, err = s.Trash(ctx, 1, base)
+		wantCount(t, "Trash of a trashed contact", n, err, 0)
+		n, err = s.Trash(ctx, 9, base)
+		wantCount(t, "Trash of an unknown contact", n, err, 0)
+
+		_, err = s.FindByID(ctx, 1)
+		wantErr(t, err, store.ErrNotFound)
+		got, err := s.FindByMobile(ctx, "9000000001")
+		must(t, err)
+		if got.DeletedAt == nil || !got.DeletedAt.Equal(base) {
+			t.Fatalf("FindByMobile of a trashed contact has DeletedAt %v, want %v", got.DeletedAt, base)
+		}
+		got, err = s.FindTrashed(ctx, 1)
+		must(t, err)
+		if got.ID != 1 {
+			t.Fatalf("FindTrashed found contact %d, want 1", got.ID)
+		}
+		_, err = s.FindTrashed(ctx, 2)
+		wantErr(t, err, store.ErrNotFound)
+
+		n, err = s.Restore(ctx, 1)
+		wantCount(t, "Restore", n, err, 1)
+		n, err = s.Restore(ctx, 1)
+		wantCount(t, "Restore of a restored contact", n, err, 0)
+		got, err = s.FindByID(ctx, 1)
+		must(t, err)
+		if got.DeletedAt != nil {
+			t.Fatalf("restored contact has DeletedAt %v", got.DeletedAt)
+		}
+	})
+
+	t.Run("TrashAllListTrashAndPurge", func(t *testing.T) {
+		s := newStore(t)
+		must(t, s.Insert(ctx, contact(1, "9000000001")))
+		must(t, s.Insert(ctx, contact(2, "9000000002")))
+		must(t, s.Insert(ctx, contact(3, "9000000003")))
+
+		_, err := s.Trash(ctx, 1, base)
+		must(t, err)
+		n, err := s.TrashAll(ctx, base.Add(time.Hour))
+		wantCount(t, "TrashAll", n, err, 2)
+		n, err = s.Count(ctx)
+		wantCount(t, "Count after TrashAll", n, err, 0)
+
+		trash, err := s.ListTrash(ctx)
+		must(t, err)
+		if got := contactIDs(trash); len(got) != 3 || got[2] != 1 {
+			t.Fatalf("ListTrash = %v, want contact 1 last as the earliest deleted", got)
+		}
+
+		n, err = s.Purge(ctx, base.Add(time.Minute))
+		wantCount(t, "Purge", n, err, 1)
+		_, err = s.FindTrashed(ctx, 1)
+		wantErr(t, err, store.ErrNotFound)
+		_, err = s.FindByMobile(ctx, "9000000001")
+		wantErr(t, err, store.ErrNotFound)
+
+		n, err = s.Purge(ctx, base.Add(2*time.Hour))
+		wantCount(t, "second Purge", n, err, 2)
+		trash, err = s.ListTrash(ctx)
+		must(t, err)
+		if len(trash) != 0 {
+			t.Fatalf("ListTrash after purging everything = %v", contactIDs(trash))
+		}
+	})
+}
+
+func contactIDs(contacts []model.Contact) []int {
+	var ids []int
+	for _, c := range contacts {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func question(id int, text string) model.Question {
+	return model.Question{
+		ID:           id,
+		Type:         model.QuestionSingleChoice,
+		Question:     text,
+		Options:      []string{"Yes", "No"},
+		CorrectAns:   "Yes",
+		Reason:       "Because",
+		Category:     "general",
+		Tags:         []string{"basics"},
+		Difficulty:   "easy",
+		CreatedAt:    base.Add(time.Duration(id) * time.Minute),
+		LastModified: base.Add(time.Duration(id) * time.Minute),
+	}
+}
+
+func questionIDs(questions []model.Question) []int {
+	var ids []int
+	for _, q := range questions {
+		ids = append(ids, q.ID)
+	}
+	return ids
+}
+
+// RunQuestionStore checks the question contract.
+func RunQuestionStore(t *testing.T, newStore func(t *testing.T) store.QuestionStore) {
+	ctx := context.Background()
+
+	// seed inserts questions 1 to 5; 2 is hidden, 3 is about maths and hard,
+	// 4 has an extra tag and 5 is in the trash.
+	seed := func(t *testing.T) store.QuestionStore {
+		s := newStore(t)
+		for id := 1; id <= 5; id++ {
+			q := question(id, fmt.Sprintf("Question number %d?", id))
+			switch id {
+			case 2:
+				q.Hidden = true
+			case 3:
+				q.Category, q.Difficulty, q.Question = "maths", "hard", "Is MATHS fun?"
+			case 4:
+				q.Tags = []string{"basics", "extra"}
+			}
+			must(t, s.Insert(ctx, q))
+		}
+		_, err := s.Trash(ctx, 5, base)
+		must(t, err)
+		return s
+	}
+
+	t.Run("InsertAndFind", func(t *testing.T) {
+		s := newStore(t)
+		want := question(1, "Is the sky blue?")
+		must(t, s.Insert(ctx, want))
+
+		got, err := s.FindByID(ctx, 1)
+		must(t, err)
+		if got.Question != want.Question || got.CorrectAns != want.CorrectAns || len(got.Options) != 2 ||
+			got.Category != want.Category || !got.CreatedAt.Equal(want.CreatedAt) {
+			t.Fatalf("FindByID = %+v, want %+v", got, want)
+		}
+		_, err = s.FindByID(ctx, 2)
+		wantErr(t, err, store.ErrNotFound)
+
+		wantErr(t, s.Insert(ctx, question(1, "Another text?")), store.ErrDuplicate)
+		wantErr(t, s.Insert(ctx, question(2, want.Question)), store.ErrDuplicate)
+
+		exists, err := s.ExistsByText(ctx, want.Question, 0)
+		must(t, err)
+		if !exists {
+			t.Error("ExistsByText missed the stored question")
+		}
+		exists, err = s.ExistsByText(ctx, want.Question, 1)
+		must(t, err)
+		if exists {
+			t.Error("ExistsByText counted the excluded question")
+		}
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		s := seed(t)
+		hidden, visible := true, false
+		for _, tc := range []struct {
+			name  string
+			query store.QuestionQuery
+			want  []int
+			total int64
+		}{
+			{"all", store.QuestionQuery{}, []int{1, 2, 3, 4}, 4},
+			{"hidden", store.QuestionQuery{Hidden: &hidden}, []int{2}, 1},
+			{"visible", store.QuestionQuery{Hidden: &visible}, []int{1, 3, 4}, 3},
+			{"search ignores case", store.QuestionQuery{Search: "maths"}, []int{3}, 1},
+			{"category", store.QuestionQuery{Category: "general"}, []int{1, 2, 4}, 3},
+			{"every tag", store.QuestionQuery{Tags: []string{"basics", "extra"}}, []int{4}, 1},
+			{"difficulty", store.QuestionQuery{Difficulty: "hard"}, []int{3}, 1},
+			{"descending", store.QuestionQuery{Desc: true}, []int{4, 3, 2, 1}, 4},
+			{"by created_at", store.QuestionQuery{SortBy: store.SortByCreatedAt, Desc: true}, []int{4, 3, 2, 1}, 4},
+			{"limit", store.QuestionQuery{Limit: 2}, []int{1, 2}, 4},
+			{"skip", store.QuestionQuery{Limit: 2, Skip: 2}, []int{3, 4}, 4},
+			{"after", store.QuestionQuery{Limit: 2, After: &store.QuestionCursor{ID: 2}}, []int{3, 4}, 4},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				page, err := s.Find(ctx, tc.query)
+				must(t, err)
+				if got := questionIDs(page.Questions); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+					t.Errorf("Find = %v, want %v", got, tc.want)
+				}
+				if page.Total != tc.total {
+					t.Errorf("Total = %d, want %d", page.Total, tc.total)
+				}
+			})
+		}
+	})
+
+	t.Run("CursorPaging", func(t *testing.T) {
+		s := seed(t)
+		query := store.QuestionQuery{SortBy: store.SortByLastModified, Limit: 3}
+		var seen []int
+		for pages := 0; ; pages++ {
+			if pages > 4 {
+				t.Fatal("paging did not finish")
+			}
+			page, err := s.Find(ctx, query)
+			must(t, err)
+			seen = append(seen, questionIDs(page.Questions)...)
+			if !page.HasMore {
+				break
+			}
+			query.After = query.CursorFor(page.Questions[len(page.Questions)-1])
+		}
+		if fmt.Sprint(seen) != "[1 2 3 4]" {
+			t.Fatalf("paging by last_modified saw %v, want [1 2 3 4]", seen)
+		}
+	})
+
+	t.Run("Counts", func(t *testing.T) {
+		s := seed(t)
+		n, err := s.Count(ctx)
+		wantCount(t, "Count", n, err, 4)
+
+		visible, hidden, err := s.CountByVisibility(ctx)
+		must(t, err)
+		if visible != 3 || hidden != 1 {
+			t.Fatalf("CountByVisibility = %d visible, %d hidden; want 3 and 1", visible, hidden)
+		}
+
+		categories, err := s.Categories(ctx)
+		must(t, err)
+		if fmt.Sprint(categories) != "[{general 3} {maths 1}]" {
+			t.Errorf("Categories = %v", categories)
+		}
+		tags, err := s.Tags(ctx)
+		must(t, err)
+		if fmt.Sprint(tags) != "[{basics 4} {extra 1}]" {
+			t.Errorf("Tags = %v", tags)
+		}
+	})
+
+	t.Run("Sample", func(t *testing.T) {
+		s := seed(t)
+		questions, err := s.Sample(ctx, 10)
+		must(t, err)
+		if len(questions) != 3 {
+			t.Fatalf("Sample(10) returned %d questions, want the 3 visible ones", len(questions))
+		}
+		for _, q := range questions {
+			if q.Hidden || q.DeletedAt != nil {
+				t.Errorf("Sample returned question %d, which is hidden or trashed", q.ID)
+			}
+		}
+		questions, err = s.Sample(ctx, 2)
+		must(t, err)
+		if len(questions) != 2 {
+			t.Fatalf("Sample(2) returned %d questions", len(questions))
+		}
+	})
+
+	t.Run("UpdateAndSetHidden", func(t *testing.T) {
+		s := seed(t)
+		q := question(1, "Rewritten?")
+		q.LastModified = base.Add(time.Hour)
+		n, err := s.Update(ctx, q)
+		wantCount(t, "Update", n, err, 1)
+		got, err := s.FindByID(ctx, 1)
+		must(t, err)
+		if got.Question != "Rewritten?" || !got.LastModified.Equal(q.LastModified) {
+			t.Fatalf("Update stored %+v", got)
+		}
+		n, err = s.Update(ctx, question(9, "Unknown?"))
+		wantCount(t, "Update of an unknown question", n, err, 0)
+
+		n, err = s.SetHidden(ctx, 1, true)
+		wantCount(t, "SetHidden", n, err, 1)
+		got, err = s.FindByID(ctx, 1)
+		must(t, err)
+		if !got.Hidden {
+			t.Fatal("SetHidden did not hide the question")
+		}
+		n, err = s.SetHidden(ctx, 9, true)
+		wantCount(t, "SetHidden of an unknown question", n, err, 0)
+	})
+
+	t.Run("TrashRestoreAndPurge", func(t *testing.T) {
+		s := seed(t)
+		_, err := s.FindByID(ctx, 5)
+		wantErr(t, err, store.ErrNotFound)
+		got, err := s.FindTrashed(ctx, 5)
+		must(t, err)
+		if got.ID != 5 {
+			t.Fatalf("FindTrashed found question %d, want 5", got.ID)
+		}
+		_, err = s.FindTrashed(ctx, 1)
+		wantErr(t, err, store.ErrNotFound)
+
+		// Trashed questions keep their text
+		exists, err := s.ExistsByText(ctx, "Question number 5?", 0)
+		must(t, err)
+		if !exists {
+			t.Error("ExistsByText missed the trashed question")
+		}
+
+		n, err := s.Trash(ctx, 1, base.Add(time.Hour))
+		wantCount(t, "Trash", n, err, 1)
+		trash, err := s.ListTrash(ctx)
+		must(t, err)
+		if got := questionIDs(trash); fmt.Sprint(got) != "[1 5]" {
+			t.Fatalf("ListTrash = %v, want [1 5], most recently deleted first", got)
+		}
+
+		n, err = s.Restore(ctx, 1)
+		wantCount(t, "Restore", n, err, 1)
+		n, err = s.Restore(ctx, 1)
+		wantCount(t, "Restore of a restored question", n, err, 0)
+
+		n, err = s.Purge(ctx, base.Add(time.Minute))
+		wantCount(t, "Purge", n, err, 1)
+		_, err = s.FindTrashed(ctx, 5)
+		wantErr(t, err, store.ErrNotFound)
+	})
+}
+
+// RunAttemptStore checks the attempt contract.
+func RunAttemptStore(t *testing.T, newStore func(t *testing.T) store.AttemptStore) {
+	ctx := context.Background()
+	s := newStore(t)
+
+	attempt := func(id int, user string, started time.Time) model.Attempt {
+		return model.Attempt{
+			ID:        id,
+			UserID:    user,
+			Status:    model.AttemptInProgress,
+			Snapshot:  []model.Question{question(1, "Is the sky blue?")},
+			StartedAt: started,
+		}
+	}
+	must(t, s.Insert(ctx, attempt(1, "alice", base)))
+	must(t, s.Insert(ctx, attempt(2, "alice", base.Add(time.Hour))))
+	must(t, s.Insert(ctx, attempt(3, "bob", base)))
+
+	got, err := s.FindByID(ctx, 1)
+	must(t, err)
+	if got.UserID != "alice" || len(got.Snapshot) != 1 || got.Snapshot[0].CorrectAns != "Yes" {
+		t.Fatalf("FindByID = %+v", got)
+	}
+	_, err = s.FindByID(ctx, 9)
+	wantErr(t, err, store.ErrNotFound)
+
+	attempts, err := s.ListByUser(ctx, "alice")
+	must(t, err)
+	if len(attempts) != 2 || attempts[0].ID != 2 || attempts[1].ID != 1 {
+		t.Fatalf("ListByUser(alice) = %+v, want attempts 2 then 1", attempts)
+	}
+	attempts, err = s.ListByUser(ctx, "carol")
+	must(t, err)
+	if len(attempts) != 0 {
+		t.Fatalf("ListByUser(carol) = %+v, want none", attempts)
+	}
+
+	submitted := attempt(1, "alice", base)
+	at := base.Add(time.Minute)
+	submitted.Status = model.AttemptSubmitted
+	submitted.SubmittedAt = &at
+	submitted.Result = &model.QuizResult{Score: 1, Total: 1}
+	must(t, s.Submit(ctx, submitted))
+	got, err = s.FindByID(ctx, 1)
+	must(t, err)
+	if got.Status != model.AttemptSubmitted || got.Result == nil || got.Result.Score != 1 {
+		t.Fatalf("Submit stored %+v", got)
+	}
+	wantErr(t, s.Submit(ctx, submitted), store.ErrNotFound)
+	unknown := attempt(9, "alice", base)
+	wantErr(t, s.Submit(ctx, unknown), store.ErrNotFound)
+}
+
+// RunRevisionStore checks that revisions are numbered per question from 1.
+func RunRevisionStore(t *testing.T, newStore func(t *testing.T) store.RevisionStore) {
+	ctx := context.Background()
+	s := newStore(t)
+
+	for i, questionID := range []int{1, 1, 2, 1} {
+		rev, err := s.Append(ctx, model.QuestionRevision{
+			QuestionID: questionID,
+			Action:     model.RevisionUpdated,
+			CreatedAt:  base.Add(time.Duration(i) * time.Minute),
+			Question:   question(questionID, fmt.Sprintf("Version %d?", i)),
+		})
+		must(t, err)
+		if want := []int{1, 2, 1, 3}[i]; rev.Revision != want {
+			t.Fatalf("Append %d numbered revision %d, want %d", i, rev.Revision, want)
+		}
+	}
+
+	revs, err := s.List(ctx, 1)
+	must(t, err)
+	var numbers []int
+	for _, rev := range revs {
+		numbers = append(numbers, rev.Revision)
+	}
+	if fmt.Sprint(numbers) != "[1 2 3]" {
+		t.Fatalf("List(1) = revisions %v, want [1 2 3]", numbers)
+	}
+
+	rev, err := s.Find(ctx, 1, 2)
+	must(t, err)
+	if rev.Question.Question != "Version 1?" {
+		t.Fatalf("Find(1, 2) = %q", rev.Question.Question)
+	}
+	_, err = s.Find(ctx, 1, 4)
+	wantErr(t, err, store.ErrNotFound)
+	_, err = s.Find(ctx, 3, 1)
+	wantErr(t, err, store.ErrNotFound)
+}
+
+// RunAuditStore checks the audit filters and ordering.
+func RunAuditStore(t *testing.T, newStore func(t *testing.T) store.AuditStore) {
+	ctx := context.Background()
+	s := newStore(t)
+
+	entries := []model.AuditEntry{
+		{Actor: "alice", Action: model.AuditCreated, TargetType: model.AuditContact, TargetID: 1, At: base},
+		{Actor: "bob", Action: model.AuditUpdated, TargetType: model.AuditContact, TargetID: 1, At: base.Add(time.Minute)},
+		{Actor: "alice", Action: model.AuditCreated, TargetType: model.AuditQuestion, TargetID: 7, At: base.Add(2 * time.Minute)},
+	}
+	for _, e := range entries {
+		must(t, s.Append(ctx, e))
+	}
+
+	for _, tc := range []struct {
+		name  string
+		query store.AuditQuery
+		want  []string
+	}{
+		{"all newest first", store.AuditQuery{}, []string{"alice/question/7", "bob/contact/1", "alice/contact/1"}},
+		{"actor", store.AuditQuery{Actor: "alice"}, []string{"alice/question/7", "alice/contact/1"}},
+		{"action", store.AuditQuery{Action: model.AuditUpdated}, []string{"bob/contact/1"}},
+		{"target", store.AuditQuery{TargetType: model.AuditContact, TargetID: 1}, []string{"bob/contact/1", "alice/contact/1"}},
+		{"since is inclusive", store.AuditQuery{Since: base.Add(time.Minute)}, []string{"alice/question/7", "bob/contact/1"}},
+		{"until", store.AuditQuery{Until: base.Add(time.Minute)}, []string{"alice/contact/1"}},
+		{"limit", store.AuditQuery{Limit: 1}, []string{"alice/question/7"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			found, err := s.Find(ctx, tc.query)
+			must(t, err)
+			var got []string
+			for _, e := range found {
+				got = append(got, fmt.Sprintf("%s/%s/%d", e.Actor, e.TargetType, e.TargetID))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("Find(%+v) = %v, want %v", tc.query, got, tc.want)
+			}
+		})
+	}
+}